@@ -1,14 +1,15 @@
 package faad2
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"io"
+	"strings"
+	"time"
 )
 
-// adtsSampleRateCount is the number of valid sample rate indices in ADTS.
-const adtsSampleRateCount = 16
-
 var (
 	// ErrInvalidADTS is returned when the ADTS stream is invalid.
 	ErrInvalidADTS = errors.New("faad2: invalid ADTS stream")
@@ -23,27 +24,58 @@ var adtsSampleRates = []uint32{
 	16000, 12000, 11025, 8000, 7350, 0, 0, 0,
 }
 
+// adtsSampleRate returns the sample rate of an ADTS sampling frequency index,
+// or a [*SampleRateIndexError] for the reserved indices and the explicit
+// frequency escape, which ADTS cannot carry.
+func adtsSampleRate(index uint8) (uint32, error) {
+	if int(index) >= len(adtsSampleRates) || adtsSampleRates[index] == 0 {
+		return 0, &SampleRateIndexError{Index: index}
+	}
+	return adtsSampleRates[index], nil
+}
+
+// sampleRateIndexFor returns the sampling frequency index of a standard AAC
+// sample rate. It reports false for any other rate.
+func sampleRateIndexFor(sampleRate uint32) (uint8, bool) {
+	for i, rate := range adtsSampleRates {
+		if rate != 0 && rate == sampleRate {
+			return uint8(i), true //nolint:gosec // i < 16
+		}
+	}
+	return 0, false
+}
+
 // ADTSReader reads and decodes audio from ADTS (Audio Data Transport Stream) format.
 //
 // ADTS is a streaming format for AAC audio, commonly used for raw AAC files (.aac)
 // and streaming applications. Unlike M4A, ADTS does not support seeking.
 //
+// Files made by concatenating ADTS streams of different formats are decoded
+// as one stream: when a frame header signals another profile, sample rate or
+// channel configuration, the decoder is replaced, and the callback set with
+// [WithFormatChange] is invoked. As at the start of the stream, the first
+// frame of each part primes the new decoder and produces no output.
+//
 // Create an ADTSReader using [OpenADTS] and release resources with [ADTSReader.Close].
 type ADTSReader struct {
-	decoder    *Decoder
-	reader     io.Reader
-	sampleRate uint32
-	channels   uint8
+	pcmStream
 
-	// PCM buffer for partial reads
-	pcmBuffer []int16
-	pcmOffset int
+	reader io.Reader
 
-	// Frame tracking
-	framesRead int64
+	// Bytes consumed from the source, counted from its position at open if
+	// it is seekable, and the offset of the first frame, for SaveState
+	consumed    *offsetReader
+	firstOffset int64
 
 	// Header buffer for reading
 	headerBuf [9]byte
+
+	// AudioSpecificConfig of the current frames, to detect format changes
+	// between concatenated streams
+	config []byte
+
+	// Samples produced by the priming decode in OpenADTS
+	openSamples int
 }
 
 // adtsHeader represents a parsed ADTS frame header.
@@ -66,37 +98,47 @@ type adtsHeader struct {
 // OpenADTS opens an ADTS stream for audio decoding.
 //
 // The reader should provide raw ADTS data starting with a valid ADTS sync word (0xFFF).
-// The function reads and decodes the first frame to initialize the decoder.
+// The function reads and decodes the first frame to initialize the decoder,
+// unless [WithDeferredPriming] is given. Reads from r are buffered; see
+// [WithBufferSize].
 //
 // Returns [ErrADTSSyncNotFound] if no valid ADTS header is found,
 // or [ErrInvalidADTS] if the header is malformed.
-func OpenADTS(ctx context.Context, r io.Reader) (*ADTSReader, error) {
-	ar := &ADTSReader{
-		reader: r,
+func OpenADTS(ctx context.Context, r io.Reader, opts ...Option) (*ADTSReader, error) {
+	o := newOptions(opts)
+
+	var gain float64
+	if o.normalizePeak > 0 {
+		open := func(ctx context.Context, r io.Reader, opts ...Option) (closingPCMReader, error) {
+			ar, err := OpenADTS(ctx, r, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return ar, nil
+		}
+		g, err := peakGain(ctx, r, o.normalizePeak, open, opts)
+		if err != nil {
+			return nil, err
+		}
+		gain = g
 	}
 
+	ar := newADTSReader(r, o)
+	ar.gain = gain
+
 	// Read and parse first header to get stream info
 	header, err := ar.readHeader()
 	if err != nil {
 		return nil, err
 	}
 
-	// Extract sample rate and channels
-	if header.samplingFreqIndex >= adtsSampleRateCount {
-		return nil, ErrInvalidADTS
-	}
-	ar.sampleRate = adtsSampleRates[header.samplingFreqIndex]
-	ar.channels = header.channelConfig
-
-	if ar.sampleRate == 0 {
-		return nil, ErrInvalidADTS
+	config, err := ar.configFromHeader(header)
+	if err != nil {
+		return nil, err
 	}
 
-	// Build AudioSpecificConfig from ADTS header
-	config := buildAudioSpecificConfig(header.profile+1, header.samplingFreqIndex, header.channelConfig)
-
 	// Create and initialize decoder
-	decoder, err := NewDecoder(ctx)
+	decoder, err := NewDecoder(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -108,6 +150,17 @@ func OpenADTS(ctx context.Context, r io.Reader) (*ADTSReader, error) {
 	}
 
 	ar.decoder = decoder
+	ar.asc, _ = parseAudioSpecificConfig(config)
+	// Implicit SBR doubles low rates, see SampleRate
+	ar.sampleRate = decoder.SampleRate()
+	if !ar.opts.extractChannel {
+		// Mono is output as stereo, see Channels
+		ar.channels = decoder.Channels()
+	}
+	if err := ar.checkChannel(); err != nil {
+		decoder.Close(ctx)
+		return nil, err
+	}
 
 	// Read first frame payload and decode (to prime the decoder)
 	payload, err := ar.readPayload(header)
@@ -115,6 +168,32 @@ func OpenADTS(ctx context.Context, r io.Reader) (*ADTSReader, error) {
 		decoder.Close(ctx)
 		return nil, err
 	}
+	first := ar.frameOf(header, payload)
+	ar.firstOffset = first.offset
+	ar.bytesParsed = first.bytes
+	ar.secondsParsed = first.seconds
+
+	if ar.opts.deferredPriming {
+		// The first Read decodes the frame like any other; it is already
+		// counted as parsed
+		pending := &containerFrame{data: payload, offset: first.offset}
+		ar.nextFrame = func() (containerFrame, error) {
+			if pending != nil {
+				frame := *pending
+				pending = nil
+				return frame, nil
+			}
+			return ar.readFrame()
+		}
+		return ar, nil
+	}
+
+	ar.bytesRead = int64(len(payload))
+	payload, err = ar.opts.transform(payload)
+	if err != nil {
+		decoder.Close(ctx)
+		return nil, err
+	}
 
 	// Decode first frame (usually produces 0 samples - priming frame)
 	pcm, err := decoder.Decode(ctx, payload)
@@ -123,114 +202,283 @@ func OpenADTS(ctx context.Context, r io.Reader) (*ADTSReader, error) {
 		return nil, err
 	}
 	ar.framesRead = 1
+	ar.trackPriming(len(pcm))
 
 	// Buffer any samples from first frame
+	ar.openSamples = len(pcm)
 	if len(pcm) > 0 {
-		ar.pcmBuffer = pcm
+		if ar.gain != 0 {
+			applyGain(pcm, ar.gain)
+		}
+		ar.pcmBuffer = ar.selectChannel(ar.clip(pcm))
 		ar.pcmOffset = 0
 	}
+	ar.trackSpan(ar.firstOffset, len(ar.pcmBuffer))
 
 	return ar, nil
 }
 
+// defaultADTSBufferSize is the default size of the buffer in front of the
+// underlying reader.
+const defaultADTSBufferSize = 32 * 1024
+
+// newADTSReader creates an unopened ADTSReader reading from r, buffered
+// according to opts.
+func newADTSReader(r io.Reader, opts options) *ADTSReader {
+	size := defaultADTSBufferSize
+	if opts.bufferSizeSet {
+		size = opts.bufferSize
+	}
+
+	deadliner, _ := r.(readDeadliner)
+	var base int64
+	if seeker, ok := r.(io.Seeker); ok {
+		if pos, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			base = pos
+		}
+	}
+
+	// In-memory and already buffered readers gain nothing from another copy.
+	switch r.(type) {
+	case *bytes.Reader, *bytes.Buffer, *strings.Reader, *bufio.Reader:
+		size = 0
+	}
+	r = opts.teeReader(r)
+	switch {
+	case size > 0 && opts.buffer != nil:
+		opts.buffer.Reset(r)
+		r = opts.buffer
+	case size > 0:
+		r = bufio.NewReaderSize(r, size)
+	}
+
+	consumed := &offsetReader{r: r, n: base}
+	ar := &ADTSReader{
+		pcmStream: pcmStream{opts: opts, deadliner: deadliner},
+		reader:    consumed,
+		consumed:  consumed,
+	}
+	ar.nextFrame = ar.readFrame
+	return ar
+}
+
+// OpenADTSBytes opens an ADTS stream held in memory.
+//
+// It is equivalent to [OpenADTS] with a [bytes.Reader], for callers such as
+// gomobile bindings that cannot easily pass an io.Reader. data must not be
+// modified while the reader is in use.
+func OpenADTSBytes(ctx context.Context, data []byte, opts ...Option) (*ADTSReader, error) {
+	return OpenADTS(ctx, bytes.NewReader(data), opts...)
+}
+
 // Read reads decoded PCM samples into the provided buffer.
 //
 // Returns the number of samples read into pcm. For stereo audio, each sample
-// pair (L, R) counts as 2 samples. Returns [io.EOF] when the stream ends.
+// pair (L, R) counts as 2 samples.
+//
+// Read follows [io.Reader] conventions: a call that returns samples always
+// returns a nil error, and any error, including [io.EOF] at the end of the
+// stream, is reported by the following call with n == 0. After io.EOF, all
+// further calls return (0, io.EOF).
 //
 // The buffer can be any size; the reader handles internal buffering.
 func (ar *ADTSReader) Read(ctx context.Context, pcm []int16) (int, error) {
-	if ar.decoder == nil {
-		return 0, ErrNotInitialized
-	}
-
-	totalRead := 0
-
-	for totalRead < len(pcm) {
-		// First, drain any buffered samples
-		if ar.pcmOffset < len(ar.pcmBuffer) {
-			n := copy(pcm[totalRead:], ar.pcmBuffer[ar.pcmOffset:])
-			ar.pcmOffset += n
-			totalRead += n
-			continue
-		}
-
-		// Read next frame
-		header, err := ar.readHeader()
-		if err != nil {
-			if errors.Is(err, io.EOF) && totalRead > 0 {
-				return totalRead, nil
-			}
-			return totalRead, err
-		}
-
-		payload, err := ar.readPayload(header)
-		if err != nil {
-			if errors.Is(err, io.EOF) && totalRead > 0 {
-				return totalRead, nil
-			}
-			return totalRead, err
-		}
+	return ar.read(ctx, pcm)
+}
 
-		// Decode frame
-		samples, err := ar.decoder.Decode(ctx, payload)
-		if err != nil {
-			return totalRead, err
-		}
-		ar.framesRead++
+// ReadTimed reads like [ADTSReader.Read] and also returns the stream time of
+// the first sample read, for aligning subtitles or transcripts with the audio.
+// The time counts the samples returned so far at the output sample rate of
+// each part of a concatenated stream, from the [WithStartOffset] offset; it
+// does not account for [ADTSReader.SetPlaybackRate]. It is 0 when n is 0.
+func (ar *ADTSReader) ReadTimed(ctx context.Context, pcm []int16) (n int, start time.Duration, err error) {
+	return ar.readTimed(ctx, pcm)
+}
 
-		if len(samples) == 0 {
-			continue
-		}
+// ReadPlanar reads decoded samples into one slice per channel, for DSP code
+// that expects planar rather than interleaved audio. len(out) must equal the
+// number of output channels, otherwise [ErrInvalidChannel] is returned.
+//
+// It returns the number of samples read per channel, at most the length of
+// the shortest slice, with the same error conventions as [ADTSReader.Read].
+// When mixing with Read, use buffer sizes that are multiples of the channel
+// count so that channels stay aligned.
+func (ar *ADTSReader) ReadPlanar(ctx context.Context, out [][]int16) (int, error) {
+	return ar.readPlanar(ctx, out)
+}
 
-		// Copy to output or buffer
-		n := copy(pcm[totalRead:], samples)
-		totalRead += n
-
-		if n < len(samples) {
-			// Buffer remaining samples
-			ar.pcmBuffer = samples
-			ar.pcmOffset = n
-		} else {
-			ar.pcmBuffer = nil
-			ar.pcmOffset = 0
-		}
-	}
+// ReadFrame decodes the next AAC frame and returns its interleaved PCM.
+//
+// Unlike [ADTSReader.Read], each call reads exactly one frame from the
+// underlying reader and returns its samples without buffering, so no latency
+// is added beyond the frame itself. Open the reader without [WithLookAhead]
+// for the lowest latency. The slice may be empty, notably for the first frame
+// while the decoder primes. Samples left buffered by an earlier Read are
+// returned before a new frame is decoded.
+//
+// Returns io.EOF at the end of the stream.
+func (ar *ADTSReader) ReadFrame(ctx context.Context) ([]int16, error) {
+	return ar.readPCMFrame(ctx)
+}
 
-	return totalRead, nil
+// ReadFrames reads exactly frames samples per channel and returns them
+// interleaved, for consumers that need fixed-size blocks such as Opus or
+// Vorbis encoders. Decoded samples beyond the block are buffered for the next
+// call, so block boundaries need not match AAC frame boundaries.
+//
+// The last block of the stream is padded with silence; the following call
+// returns io.EOF. Returns [ErrInvalidFrameCount] if frames is not positive.
+// ReadFrames and [ADTSReader.Read] share the same buffer and may be mixed.
+func (ar *ADTSReader) ReadFrames(ctx context.Context, frames int) ([]int16, error) {
+	return ar.readFrames(ctx, frames)
 }
 
 // SampleRate returns the audio sample rate in Hz (e.g., 44100, 48000).
+//
+// This is the rate of the decoder output. It is known when the reader is
+// opened: the decoder assumes implicit SBR (HE-AAC) for rates signaled in the
+// ADTS header up to 24 kHz, and outputs at twice that rate. If the decoder
+// discovers SBR in a stream at a higher rate, SampleRate reports the actual
+// output rate once the frame has been decoded, and the callback set with
+// [WithFormatChange] is invoked.
 func (ar *ADTSReader) SampleRate() uint32 {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
 	return ar.sampleRate
 }
 
-// Channels returns the number of audio channels (1 for mono, 2 for stereo).
+// Channels returns the number of audio channels (1 for mono, 2 for stereo,
+// 8 for 7.1). Returns 0 if the layout is defined in the bitstream, and 1 when
+// a single channel is selected with [WithChannel].
+//
+// This is the channel count of the decoder output, which can differ from the
+// count signaled in the ADTS header: the decoder outputs mono streams as
+// stereo, in case they carry parametric stereo (HE-AAC v2).
 func (ar *ADTSReader) Channels() uint8 {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
 	return ar.channels
 }
 
+// PCMFormat returns the format of the samples returned by Read: 16-bit
+// signed, interleaved, in host byte order. Convert them to the format of an
+// audio output with [PCMFormat.Append].
+func (ar *ADTSReader) PCMFormat() PCMFormat {
+	return readerPCMFormat
+}
+
+// SetPlaybackRate changes the playback speed of subsequent reads.
+//
+// A rate of 1.0 is normal speed; 1.5 plays 50% faster and yields proportionally
+// fewer samples. Speed is changed by simple resampling, so pitch shifts with the
+// rate. Samples already buffered from a previous Read are returned unchanged.
+//
+// Returns [ErrInvalidPlaybackRate] if rate is not in the range (0, 4].
+func (ar *ADTSReader) SetPlaybackRate(rate float64) error {
+	return ar.setPlaybackRate(rate)
+}
+
+// PlaybackRate returns the current playback rate (1.0 is normal speed).
+func (ar *ADTSReader) PlaybackRate() float64 {
+	return ar.playbackRate()
+}
+
 // FramesRead returns the number of AAC frames decoded so far.
 //
 // This can be used to estimate playback position when the frame duration is known
 // (typically 1024 samples per frame for AAC-LC).
 func (ar *ADTSReader) FramesRead() int64 {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
 	return ar.framesRead
 }
 
+// Info returns a snapshot of the stream properties and read progress.
+func (ar *ADTSReader) Info() Info {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	info := ar.info("ADTS", ar.sampleRate, ar.channels)
+	info.Duration = ar.duration()
+	return info
+}
+
+// Duration returns the estimated total duration of the stream, extrapolated
+// from the average frame size so far and the size given with
+// [WithSizeHint]. The estimate is exact for constant-bitrate streams and
+// improves as more frames are read. Concatenated streams count each part at
+// its own sample rate. Returns 0 without a size hint.
+func (ar *ADTSReader) Duration() time.Duration {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	return ar.duration()
+}
+
+// duration implements Duration. The caller must hold ar.mu.
+func (ar *ADTSReader) duration() time.Duration {
+	if ar.opts.sizeHint <= 0 || ar.bytesParsed == 0 {
+		return 0
+	}
+
+	seconds := ar.secondsParsed * float64(ar.opts.sizeHint) / float64(ar.bytesParsed)
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// String summarizes the reader state for logging, for example
+// "faad2.ADTSReader(AAC-LC, 44100 Hz, 2 ch, frame 120, 2.786s decoded, 0 skipped)".
+func (ar *ADTSReader) String() string {
+	return ar.describe("ADTSReader", ar.Info())
+}
+
+// PrimingSamples returns the number of samples per channel that the decoder
+// withheld at the start of the stream instead of outputting them.
+//
+// FAAD2 outputs nothing for the first frame while its filter banks fill, so
+// decoded audio starts this many samples later in the source timeline than
+// the first frame: 1024, or 2048 with SBR upsampling. Callers aligning audio
+// with other media can compensate by this amount. The value is final once a
+// Read has returned samples.
+func (ar *ADTSReader) PrimingSamples() int {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	return ar.primingSamples()
+}
+
+// OpenSamples returns the number of samples the priming decode in [OpenADTS]
+// produced, which are buffered for the first Read. It is usually 0, as the
+// decoder withholds its first frame, and always 0 with [WithDeferredPriming].
+func (ar *ADTSReader) OpenSamples() int {
+	return ar.openSamples
+}
+
+// FramesSkipped returns the number of corrupt frames replaced with silence
+// or, with [WithRepeatConcealment], with the previous frame.
+//
+// It is always 0 unless the reader was opened with [WithSkipCorruptFrames].
+func (ar *ADTSReader) FramesSkipped() int64 {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	return ar.framesSkipped
+}
+
+// Concealment returns the number of corrupt frames concealed with each
+// method, for monitoring the audible impact of stream errors.
+func (ar *ADTSReader) Concealment() Concealment {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	return ar.concealment
+}
+
 // Close releases all resources associated with the reader.
 //
-// After Close is called, the reader cannot be reused.
-// It is safe to call Close multiple times; subsequent calls are no-ops.
+// After Close is called, the reader cannot be reused and Read returns
+// [ErrDecoderClosed]. It is safe to call Close multiple times, and from
+// another goroutine while a Read is in progress; Close then waits for that
+// Read to return.
 //
 // Note: Close does not close the underlying io.Reader passed to [OpenADTS].
 func (ar *ADTSReader) Close(ctx context.Context) error {
-	if ar.decoder != nil {
-		err := ar.decoder.Close(ctx)
-		ar.decoder = nil
-		return err
-	}
-	return nil
+	return ar.close(ctx)
 }
 
 // maxResyncBytes is the maximum number of bytes to search for a sync word
@@ -284,6 +532,83 @@ func (ar *ADTSReader) readHeader() (*adtsHeader, error) {
 	return header, nil
 }
 
+// configFromHeader records the stream format from the first frame header and
+// builds the matching AudioSpecificConfig.
+func (ar *ADTSReader) configFromHeader(header *adtsHeader) ([]byte, error) {
+	// Extract sample rate and channels
+	rate, err := adtsSampleRate(header.samplingFreqIndex)
+	if err != nil {
+		return nil, err
+	}
+	ar.sampleRate = rate
+	ar.coreSampleRate = ar.sampleRate
+	channels, err := channelCount(header.channelConfig)
+	if err != nil {
+		return nil, err
+	}
+	ar.channels = channels
+	if ar.opts.extractChannel {
+		ar.channels = 1
+	}
+
+	// Build AudioSpecificConfig from ADTS header
+	ar.config = buildAudioSpecificConfig(header.profile+1, header.samplingFreqIndex, header.channelConfig)
+	return ar.config, nil
+}
+
+// checkFormat returns the config of a frame whose header signals another
+// format than the frames before it, as where ADTS files were concatenated,
+// so that the decoder is replaced before decoding it, or nil. Headers
+// signaling an invalid format are left to the decoder.
+func (ar *ADTSReader) checkFormat(header *adtsHeader) []byte {
+	config := buildAudioSpecificConfig(header.profile+1, header.samplingFreqIndex, header.channelConfig)
+	if bytes.Equal(config, ar.config) {
+		return nil
+	}
+	if _, err := adtsSampleRate(header.samplingFreqIndex); err != nil {
+		return nil
+	}
+	if _, err := channelCount(header.channelConfig); err != nil {
+		return nil
+	}
+	ar.config = config
+	return config
+}
+
+// readFrame reads the next ADTS frame and returns its AAC payload.
+//
+// A stream that ends partway through a frame returns [ErrTruncated].
+func (ar *ADTSReader) readFrame() (containerFrame, error) {
+	header, err := ar.readHeader()
+	if err == nil {
+		var payload []byte
+		payload, err = ar.readPayload(header)
+		if err == nil {
+			frame := ar.frameOf(header, payload)
+			frame.config = ar.checkFormat(header)
+			return frame, nil
+		}
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return containerFrame{}, ErrTruncated
+	}
+	return containerFrame{}, err
+}
+
+// frameOf returns the frame just read with its header, with the offset of
+// the frame and the audio duration it adds.
+func (ar *ADTSReader) frameOf(header *adtsHeader, payload []byte) containerFrame {
+	frame := containerFrame{
+		data:   payload,
+		offset: ar.consumed.n - int64(header.frameLength),
+		bytes:  int64(header.frameLength),
+	}
+	if rate := adtsSampleRates[header.samplingFreqIndex]; rate > 0 {
+		frame.seconds = float64(coreFrameLength*(int(header.numRawDataBlocks)+1)) / float64(rate)
+	}
+	return frame
+}
+
 // readPayload reads the AAC frame payload after the header.
 func (ar *ADTSReader) readPayload(header *adtsHeader) ([]byte, error) {
 	headerSize := uint16(7)
@@ -292,7 +617,11 @@ func (ar *ADTSReader) readPayload(header *adtsHeader) ([]byte, error) {
 	}
 
 	if header.frameLength <= headerSize {
-		return nil, ErrInvalidADTS
+		return nil, &RangeError{Field: "ADTS frame length", Value: int64(header.frameLength), Err: ErrInvalidADTS}
+	}
+
+	if limit := ar.opts.limits.MaxFrameSize; limit > 0 && int(header.frameLength) > limit {
+		return nil, ErrLimitExceeded
 	}
 
 	payloadSize := header.frameLength - headerSize
@@ -302,12 +631,12 @@ func (ar *ADTSReader) readPayload(header *adtsHeader) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-
 	return payload, nil
 }
 
 // buildAudioSpecificConfig builds the AAC AudioSpecificConfig from ADTS header info.
-// This is needed to initialize the decoder.
+// This is needed to initialize the decoder. samplingFreqIndex must not be the
+// explicit frequency escape, which takes 24 more bits.
 func buildAudioSpecificConfig(objectType, samplingFreqIndex, channelConfig uint8) []byte {
 	// AudioSpecificConfig structure:
 	// - audioObjectType (5 bits)
@@ -333,8 +662,13 @@ func buildAudioSpecificConfig(objectType, samplingFreqIndex, channelConfig uint8
 // The data slice must contain at least 7 bytes (the minimum ADTS header size).
 //
 // Returns the sample rate in Hz, channel count, and frame length in bytes
-// (including the header). Returns [ErrADTSSyncNotFound] if the sync word is
-// not found, or [ErrInvalidADTS] if the header is too short or malformed.
+// (including the header). Channel configuration 7 is reported as 8 channels
+// (7.1), and 0 (layout defined in the bitstream) as 0.
+//
+// Returns [ErrADTSSyncNotFound] if the sync word is not found,
+// [ErrUnsupportedSampleRate] for a reserved sampling frequency index, a
+// [*RangeError] if the frame length leaves no room for a payload, or
+// [ErrInvalidADTS] if the header is too short or malformed.
 func ParseADTSHeader(data []byte) (sampleRate uint32, channels uint8, frameLength uint16, err error) {
 	if len(data) < 7 {
 		return 0, 0, 0, ErrInvalidADTS
@@ -346,18 +680,95 @@ func ParseADTSHeader(data []byte) (sampleRate uint32, channels uint8, frameLengt
 		return 0, 0, 0, ErrADTSSyncNotFound
 	}
 
-	samplingFreqIndex := (data[2] >> 2) & 0x0F
-	if int(samplingFreqIndex) >= len(adtsSampleRates) {
-		return 0, 0, 0, ErrInvalidADTS
+	sampleRate, err = adtsSampleRate((data[2] >> 2) & 0x0F)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	channels, err = channelCount(((data[2] & 0x01) << 2) | ((data[3] >> 6) & 0x03))
+	if err != nil {
+		return 0, 0, 0, err
 	}
-
-	sampleRate = adtsSampleRates[samplingFreqIndex]
-	channels = ((data[2] & 0x01) << 2) | ((data[3] >> 6) & 0x03)
 	frameLength = (uint16(data[3]&0x03) << 11) | (uint16(data[4]) << 3) | (uint16(data[5]>>5) & 0x07)
+	headerSize := uint16(7)
+	if data[1]&0x01 == 0 {
+		headerSize = 9 // CRC present
+	}
+	if frameLength <= headerSize {
+		return 0, 0, 0, &RangeError{Field: "ADTS frame length", Value: int64(frameLength), Err: ErrInvalidADTS}
+	}
 
 	return sampleRate, channels, frameLength, nil
 }
 
+// ADTSPayload returns the raw AAC data of an ADTS frame, the part after the
+// header and its optional CRC, as [Decoder.Decode] expects it. The returned
+// slice shares the frame's memory.
+//
+// Returns the errors of [ParseADTSHeader], or [ErrTruncated] if frame is
+// shorter than the frame length in its header.
+func ADTSPayload(frame []byte) ([]byte, error) {
+	_, _, frameLength, err := ParseADTSHeader(frame)
+	if err != nil {
+		return nil, err
+	}
+	if len(frame) < int(frameLength) {
+		return nil, ErrTruncated
+	}
+	headerSize := 7
+	if frame[1]&0x01 == 0 {
+		headerSize = 9 // CRC present
+	}
+	return frame[headerSize:frameLength], nil
+}
+
+// maxADTSFrameLength is the largest frame length an ADTS header can signal.
+const maxADTSFrameLength = 1<<13 - 1
+
+// BuildADTSHeader returns a 7-byte ADTS header (without CRC) for a frame
+// carrying payloadLen bytes of raw AAC data, at least one. It is the inverse
+// of [ParseADTSHeader].
+//
+// objectType is the MPEG-4 audio object type, 1 (Main) to 4 (LTP); use 2 for
+// AAC-LC. sampleRate must be one of the standard AAC rates, and channels is
+// the channel count (1-6, or 8 for 7.1; 0 if the layout is given by a program
+// config element in the payload). The buffer fullness field is set to 0x7FF
+// (variable bitrate).
+//
+// Returns [ErrUnsupportedSampleRate] for a non-standard sample rate,
+// [ErrInvalidChannelConfig] for an unsupported channel count, or
+// [ErrInvalidADTS] if another value cannot be represented in an ADTS header.
+func BuildADTSHeader(objectType uint8, sampleRate uint32, channels uint8, payloadLen int) ([]byte, error) {
+	if objectType < 1 || objectType > 4 {
+		return nil, ErrInvalidADTS
+	}
+
+	freqIndex, ok := sampleRateIndexFor(sampleRate)
+	if !ok {
+		return nil, ErrUnsupportedSampleRate
+	}
+
+	channelConfig, err := channelConfigFor(channels)
+	if err != nil {
+		return nil, err
+	}
+
+	frameLength := 7 + payloadLen
+	if payloadLen <= 0 || frameLength > maxADTSFrameLength {
+		return nil, ErrInvalidADTS
+	}
+
+	const bufferFullness = 0x7FF
+	return []byte{
+		0xFF,
+		0xF1, // MPEG-4, layer 0, no CRC
+		(objectType-1)<<6 | freqIndex<<2 | channelConfig>>2,
+		channelConfig<<6 | byte(frameLength>>11),
+		byte(frameLength >> 3),
+		byte(frameLength<<5) | bufferFullness>>6,
+		byte(bufferFullness << 2 & 0xFC), // one raw data block
+	}, nil
+}
+
 // resync attempts to find the next valid ADTS sync word after desynchronization.
 // It searches up to maxResyncBytes bytes for a valid sync word.
 // On success, ar.headerBuf contains the new header.
@@ -1,10 +1,13 @@
 package faad2
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
 	"os"
 	"testing"
+	"time"
 )
 
 const testAACFile = "testdata/test.aac"
@@ -58,6 +61,93 @@ func TestParseADTSHeaderInvalid(t *testing.T) {
 	if !errors.Is(err, ErrInvalidADTS) {
 		t.Errorf("expected ErrInvalidADTS, got %v", err)
 	}
+
+	// Frame lengths leaving no payload
+	for _, length := range []int{3, 7} {
+		header, err := BuildADTSHeader(2, 44100, 2, 1)
+		if err != nil {
+			t.Fatalf("BuildADTSHeader failed: %v", err)
+		}
+		header[3] &^= 0x03
+		header[4] = byte(length >> 3)
+		header[5] = header[5]&0x1F | byte(length<<5)
+		_, _, _, err = ParseADTSHeader(header)
+		var rangeErr *RangeError
+		if !errors.As(err, &rangeErr) || rangeErr.Value != int64(length) || !errors.Is(err, ErrInvalidADTS) {
+			t.Errorf("expected RangeError for frame length %d, got %v", length, err)
+		}
+		if _, err := ADTSPayload(header); !errors.Is(err, ErrInvalidADTS) {
+			t.Errorf("ADTSPayload: expected ErrInvalidADTS for frame length %d, got %v", length, err)
+		}
+	}
+}
+
+func TestParseADTSHeaderSampleRateIndex(t *testing.T) {
+	for _, index := range []byte{13, 14, 15} {
+		header := buildTestADTSStreamAt(index, 1)
+		_, _, _, err := ParseADTSHeader(header)
+		var indexErr *SampleRateIndexError
+		if !errors.Is(err, ErrUnsupportedSampleRate) || !errors.As(err, &indexErr) || indexErr.Index != index {
+			t.Errorf("index %d: expected SampleRateIndexError, got %v", index, err)
+		}
+	}
+
+	sampleRate, _, _, err := ParseADTSHeader(buildTestADTSStreamAt(12, 1))
+	if err != nil {
+		t.Fatalf("ParseADTSHeader failed: %v", err)
+	}
+	if sampleRate != 7350 {
+		t.Errorf("expected sample rate 7350, got %d", sampleRate)
+	}
+}
+
+func TestParseADTSHeaderChannelConfig7(t *testing.T) {
+	// AAC-LC, 44.1 kHz, channelConfig 7 (7.1 surround)
+	header := []byte{0xFF, 0xF1, 0x51, 0xC0, 0x20, 0x1F, 0xFC}
+
+	_, channels, _, err := ParseADTSHeader(header)
+	if err != nil {
+		t.Fatalf("ParseADTSHeader failed: %v", err)
+	}
+	if channels != 8 {
+		t.Errorf("expected 8 channels for channelConfig 7, got %d", channels)
+	}
+}
+
+func TestBuildADTSHeaderInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		objectType uint8
+		sampleRate uint32
+		channels   uint8
+		payloadLen int
+		want       error
+	}{
+		{"object type", 5, 44100, 2, 1, ErrInvalidADTS},
+		{"sample rate", 2, 44000, 2, 1, ErrUnsupportedSampleRate},
+		{"channels", 2, 44100, 7, 1, ErrInvalidChannelConfig},
+		{"empty payload", 2, 44100, 2, 0, ErrInvalidADTS},
+		{"payload length", 2, 44100, 2, maxADTSFrameLength, ErrInvalidADTS},
+	}
+	for _, tt := range tests {
+		_, err := BuildADTSHeader(tt.objectType, tt.sampleRate, tt.channels, tt.payloadLen)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	// The same input is rejected alike by InitRaw
+	ctx := context.Background()
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(ctx)
+	_, buildErr := BuildADTSHeader(2, 44000, 2, 1)
+	initErr := dec.InitRaw(ctx, 44000, 2, 2)
+	if Category(buildErr) != Category(initErr) {
+		t.Errorf("expected the same category, got %v and %v", Category(buildErr), Category(initErr))
+	}
 }
 
 func TestOpenADTS(t *testing.T) {
@@ -89,6 +179,24 @@ func TestOpenADTS(t *testing.T) {
 	}
 }
 
+func TestOpenADTSBytes(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(10)
+
+	reader, err := OpenADTSBytes(ctx, stream)
+	if err != nil {
+		t.Fatalf("OpenADTSBytes failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	if reader.SampleRate() != 44100 {
+		t.Errorf("expected sample rate 44100, got %d", reader.SampleRate())
+	}
+	if readAllSamples(t, reader.Read, 4096) == 0 {
+		t.Error("expected decoded samples")
+	}
+}
+
 func TestADTSRead(t *testing.T) {
 	ctx := context.Background()
 	testFile := testAACFile
@@ -239,11 +347,11 @@ func TestADTSReadAfterClose(t *testing.T) {
 		t.Fatalf("Close failed: %v", err)
 	}
 
-	// Try to read after close - should get ErrNotInitialized
+	// Try to read after close - should get ErrDecoderClosed
 	pcm := make([]int16, 4096)
 	_, err = reader.Read(ctx, pcm)
-	if err == nil {
-		t.Error("expected error when reading after Close")
+	if !errors.Is(err, ErrDecoderClosed) {
+		t.Errorf("expected ErrDecoderClosed when reading after Close, got %v", err)
 	}
 }
 
@@ -268,3 +376,284 @@ func TestBuildAudioSpecificConfig(t *testing.T) {
 		}
 	}
 }
+
+// silentStereoFrame is a raw AAC-LC frame (CPE) that decodes to stereo silence.
+var silentStereoFrame = []byte{0x21, 0x00, 0x49, 0x90, 0x02, 0x19, 0x00, 0x23, 0x80}
+
+// buildTestADTSStream builds an in-memory ADTS stream of silent AAC-LC
+// 44100Hz stereo frames, so tests do not depend on generated audio files.
+func buildTestADTSStream(frames int) []byte {
+	return buildTestADTSStreamAt(4, frames)
+}
+
+// buildTestADTSStreamAt builds a stream of silent AAC-LC stereo frames with
+// the given sampling frequency index.
+func buildTestADTSStreamAt(samplingFreqIndex byte, frames int) []byte {
+	return buildTestADTSStreamOf(samplingFreqIndex, 2, silentStereoFrame, frames)
+}
+
+// silentMonoFrame is a raw AAC-LC frame (SCE) that decodes to mono silence.
+var silentMonoFrame = []byte{0x00, 0xC8, 0x00, 0x07}
+
+// silentThreeChannelFrame is a raw AAC-LC frame (SCE and CPE, channel
+// configuration 3) that decodes to three channels of silence.
+var silentThreeChannelFrame = []byte{0x00, 0xC8, 0x00, 0x01, 0x08, 0x02, 0x4C, 0x80, 0x10, 0xC8, 0x01, 0x1C}
+
+// buildTestADTSStreamOf builds a stream repeating an AAC-LC payload with the
+// given sampling frequency index and channel configuration.
+func buildTestADTSStreamOf(samplingFreqIndex, channelConfig byte, payload []byte, frames int) []byte {
+	frameLen := 7 + len(payload)
+	var stream []byte
+	for range frames {
+		header := []byte{
+			0xFF,
+			0xF1, // MPEG-4, layer 0, no CRC
+			(1 << 6) | (samplingFreqIndex << 2) | (channelConfig >> 2), // AAC-LC
+			(channelConfig << 6) | byte(frameLen>>11),
+			byte(frameLen >> 3),
+			byte(frameLen<<5) | 0x1F,
+			0xFC,
+		}
+		stream = append(stream, header...)
+		stream = append(stream, payload...)
+	}
+	return stream
+}
+
+func TestADTSSampleRateUpdateOnSBR(t *testing.T) {
+	ctx := context.Background()
+
+	// FAAD2 assumes implicit SBR for core rates up to 24kHz and upsamples
+	var changedRate uint32
+	onChange := func(sampleRate uint32, _ uint8) {
+		changedRate = sampleRate
+	}
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStreamAt(7, 5)), WithFormatChange(onChange))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	if reader.SampleRate() != 44100 {
+		t.Errorf("expected output sample rate 44100 before decoding, got %d", reader.SampleRate())
+	}
+	if info := reader.Info(); info.SampleRate != 44100 {
+		t.Errorf("expected Info to report 44100 Hz, got %d", info.SampleRate)
+	}
+
+	if _, err := reader.Read(ctx, make([]int16, 16)); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if reader.SampleRate() != 44100 {
+		t.Errorf("expected output sample rate 44100 after decoding, got %d", reader.SampleRate())
+	}
+	if changedRate != 0 {
+		t.Errorf("expected no format change callback, got %d", changedRate)
+	}
+}
+
+func TestADTS7350(t *testing.T) {
+	ctx := context.Background()
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStreamAt(12, 5)))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	if reader.SampleRate() != 14700 {
+		t.Errorf("expected output sample rate 14700 before decoding, got %d", reader.SampleRate())
+	}
+	if _, err := reader.ReadFrame(ctx); err != nil {
+		t.Fatalf("ReadFrame failed: %v", err)
+	}
+	if reader.SampleRate() != 14700 {
+		t.Errorf("expected output sample rate 14700 after decoding, got %d", reader.SampleRate())
+	}
+}
+
+func TestOpenADTSReservedSampleRate(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStreamAt(13, 5)))
+	if !errors.Is(err, ErrUnsupportedSampleRate) {
+		t.Errorf("expected ErrUnsupportedSampleRate, got %v", err)
+	}
+	if errors.Is(err, ErrInvalidADTS) {
+		t.Error("reserved index should not match ErrInvalidADTS")
+	}
+}
+
+func TestADTSMonoOutputChannels(t *testing.T) {
+	ctx := context.Background()
+
+	// FAAD2 upmixes mono to stereo in case parametric stereo is present
+	var changedChannels uint8
+	onChange := func(_ uint32, channels uint8) {
+		changedChannels = channels
+	}
+
+	stream := buildTestADTSStreamOf(4, 1, silentMonoFrame, 5)
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream), WithFormatChange(onChange))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	if reader.Channels() != 2 {
+		t.Errorf("expected 2 output channels before decoding, got %d", reader.Channels())
+	}
+	if info := reader.Info(); info.Channels != 2 {
+		t.Errorf("expected Info to report 2 channels, got %d", info.Channels)
+	}
+
+	pcm, err := reader.ReadFrame(ctx)
+	if err != nil {
+		t.Fatalf("ReadFrame failed: %v", err)
+	}
+	if reader.Channels() != 2 {
+		t.Errorf("expected 2 output channels after decoding, got %d", reader.Channels())
+	}
+	if changedChannels != 0 {
+		t.Errorf("expected no format change callback, got %d channels", changedChannels)
+	}
+	if len(pcm) != 2*1024 {
+		t.Errorf("expected %d interleaved samples, got %d", 2*1024, len(pcm))
+	}
+}
+
+func TestADTSMonoReadPlanar(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStreamOf(4, 1, silentMonoFrame, 5)
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	out := make([][]int16, reader.Channels())
+	for i := range out {
+		out[i] = make([]int16, 1024)
+	}
+	n, err := reader.ReadPlanar(ctx, out)
+	if err != nil {
+		t.Fatalf("ReadPlanar failed: %v", err)
+	}
+	if n == 0 {
+		t.Error("expected samples from ReadPlanar")
+	}
+}
+
+func TestOpenADTSDeferredPriming(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(5)
+
+	eager, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer eager.Close(ctx)
+
+	deferred, err := OpenADTS(ctx, bytes.NewReader(stream), WithDeferredPriming())
+	if err != nil {
+		t.Fatalf("OpenADTS with deferred priming failed: %v", err)
+	}
+	defer deferred.Close(ctx)
+
+	if eager.FramesRead() != 1 || deferred.FramesRead() != 0 {
+		t.Errorf("expected 1 and 0 frames decoded at open, got %d and %d", eager.FramesRead(), deferred.FramesRead())
+	}
+	if eager.OpenSamples() != 0 || deferred.OpenSamples() != 0 {
+		t.Errorf("expected no samples from priming, got %d and %d", eager.OpenSamples(), deferred.OpenSamples())
+	}
+
+	eagerTotal := readAllSamples(t, eager.Read, 4096)
+	deferredTotal := readAllSamples(t, deferred.Read, 4096)
+	if eagerTotal != 4*2048 || deferredTotal != eagerTotal {
+		t.Errorf("expected %d samples from both readers, got %d and %d", 4*2048, eagerTotal, deferredTotal)
+	}
+	if deferred.FramesRead() != 5 || deferred.PrimingSamples() != eager.PrimingSamples() {
+		t.Errorf("unexpected deferred state: %d frames, %d priming samples", deferred.FramesRead(), deferred.PrimingSamples())
+	}
+}
+
+func TestADTSConcatenated(t *testing.T) {
+	ctx := context.Background()
+
+	// Two streams at different rates, as produced by concatenating files
+	stream := append(buildTestADTSStreamAt(4, 5), buildTestADTSStreamAt(3, 5)...)
+	part1 := 5 * 1024 * time.Second / 44100
+	part2 := 5 * 1024 * time.Second / 48000
+
+	for _, lookAhead := range []bool{false, true} {
+		var changedRate uint32
+		opts := []Option{
+			WithSizeHint(int64(len(stream))),
+			WithFormatChange(func(sampleRate uint32, _ uint8) { changedRate = sampleRate }),
+		}
+		if lookAhead {
+			opts = append(opts, WithLookAhead())
+		}
+		reader, err := OpenADTS(ctx, bytes.NewReader(stream), opts...)
+		if err != nil {
+			t.Fatalf("OpenADTS failed: %v", err)
+		}
+
+		// Each part primes its own decoder, so each yields 4 frames
+		var starts []time.Duration
+		pcm := make([]int16, 2048)
+		for {
+			n, start, err := reader.ReadTimed(ctx, pcm)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("ReadTimed failed: %v", err)
+			}
+			if n != len(pcm) {
+				t.Fatalf("expected %d samples, got %d", len(pcm), n)
+			}
+			starts = append(starts, start)
+		}
+		if len(starts) != 8 {
+			t.Fatalf("expected 8 frames of output, got %d", len(starts))
+		}
+		if want := 4 * 1024 * time.Second / 44100; starts[4] != want {
+			t.Errorf("expected second part to start at %v, got %v", want, starts[4])
+		}
+		if want := starts[4] + 1024*time.Second/48000; starts[5] != want {
+			t.Errorf("expected %v after a frame of the second part, got %v", want, starts[5])
+		}
+
+		if reader.SampleRate() != 48000 || changedRate != 48000 {
+			t.Errorf("expected sample rate 48000 (callback %d), got %d", changedRate, reader.SampleRate())
+		}
+		if d := reader.Duration(); d-(part1+part2) > time.Microsecond || part1+part2-d > time.Microsecond {
+			t.Errorf("expected duration %v, got %v", part1+part2, d)
+		}
+		if d := reader.Info().Decoded; d-(part1+part2) > time.Microsecond || part1+part2-d > time.Microsecond {
+			t.Errorf("expected %v decoded, got %v", part1+part2, d)
+		}
+		reader.Close(ctx)
+	}
+}
+
+func TestADTSPayload(t *testing.T) {
+	frame := buildTestADTSStream(2)
+	frameLen := 7 + len(silentStereoFrame)
+
+	// Trailing data after the frame is left out
+	payload, err := ADTSPayload(frame)
+	if err != nil {
+		t.Fatalf("ADTSPayload failed: %v", err)
+	}
+	if !bytes.Equal(payload, silentStereoFrame) {
+		t.Errorf("got % X, want % X", payload, silentStereoFrame)
+	}
+
+	if _, err := ADTSPayload(frame[:frameLen-1]); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated, got %v", err)
+	}
+}
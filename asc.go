@@ -0,0 +1,293 @@
+package faad2
+
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	// ErrInvalidChannelConfig is returned when a channel configuration is
+	// outside the range supported by AAC (0-7).
+	ErrInvalidChannelConfig = errors.New("faad2: invalid channel configuration")
+
+	// ErrUnsupportedSampleRate is returned when a stream signals a reserved
+	// sampling frequency index (13 or 14), or the explicit frequency escape
+	// (15) in an ADTS header, which cannot carry it. The concrete error is a
+	// [*SampleRateIndexError].
+	ErrUnsupportedSampleRate = errors.New("faad2: unsupported sampling frequency index")
+)
+
+// SampleRateIndexError reports a sampling frequency index that cannot be
+// decoded. It matches [ErrUnsupportedSampleRate] with [errors.Is].
+type SampleRateIndexError struct {
+	// Index is the signaled sampling frequency index.
+	Index uint8
+}
+
+func (e *SampleRateIndexError) Error() string {
+	return "faad2: unsupported sampling frequency index " + strconv.Itoa(int(e.Index))
+}
+
+// Is reports whether target is [ErrUnsupportedSampleRate].
+func (e *SampleRateIndexError) Is(target error) bool {
+	return target == ErrUnsupportedSampleRate
+}
+
+// Sampling frequency indices with special handling
+const (
+	sampleRateIndex8000     = 11
+	sampleRateIndex7350     = 12
+	sampleRateIndexExplicit = 15
+)
+
+// maxChannelConfig is the highest channelConfiguration value FAAD2 supports.
+const maxChannelConfig = 7
+
+// channelCount returns the number of output channels for an AAC
+// channelConfiguration value.
+//
+// Values 1-6 equal the channel count, 7 is 7.1 surround (8 channels), and 0
+// means the layout is defined by a program config element in the bitstream,
+// in which case the count is unknown until decoding and 0 is returned.
+func channelCount(channelConfig uint8) (uint8, error) {
+	switch {
+	case channelConfig == 7:
+		return 8, nil
+	case channelConfig > maxChannelConfig:
+		return 0, ErrInvalidChannelConfig
+	default:
+		return channelConfig, nil
+	}
+}
+
+// channelConfigFor returns the channelConfiguration value for a channel
+// count, the inverse of channelCount.
+func channelConfigFor(channels uint8) (uint8, error) {
+	switch {
+	case channels == 8:
+		return 7, nil
+	case channels > 6:
+		return 0, ErrInvalidChannelConfig
+	default:
+		return channels, nil
+	}
+}
+
+// audioSpecificConfig holds the leading fields of an AudioSpecificConfig and
+// its explicit SBR and PS signaling.
+type audioSpecificConfig struct {
+	objectType        uint8
+	samplingFreqIndex uint8
+	sampleRate        uint32
+	channelConfig     uint8
+
+	// Explicitly signaled SBR (HE-AAC) and PS (HE-AAC v2), and the output
+	// sample rate of SBR. objectType and sampleRate describe the core.
+	sbr           bool
+	ps            bool
+	extSampleRate uint32
+}
+
+// Audio object types with special handling
+const (
+	objectTypeSBR = 5
+	objectTypePS  = 29
+)
+
+// Sync extension types of backward compatible SBR and PS signaling
+const (
+	syncExtensionSBR = 0x2B7
+	syncExtensionPS  = 0x548
+)
+
+// errShortConfig is returned when an AudioSpecificConfig ends prematurely.
+var errShortConfig = errors.New("faad2: AudioSpecificConfig too short")
+
+// parseAudioSpecificConfig parses the object type, sampling frequency and
+// channel configuration of an AudioSpecificConfig (ISO/IEC 14496-3 1.6.2.1),
+// and the explicit SBR and PS signaling that may follow, either hierarchical
+// (object type 5 or 29) or backward compatible (sync extension).
+func parseAudioSpecificConfig(config []byte) (audioSpecificConfig, error) {
+	br := bitReader{data: config}
+	var asc audioSpecificConfig
+
+	asc.objectType = br.readObjectType()
+	asc.samplingFreqIndex, asc.sampleRate = br.readSampleRate()
+	asc.channelConfig = uint8(br.read(4)) //nolint:gosec // 4 bits
+
+	if br.overflow {
+		return asc, errShortConfig
+	}
+
+	if asc.objectType == objectTypeSBR || asc.objectType == objectTypePS {
+		asc.sbr = true
+		asc.ps = asc.objectType == objectTypePS
+		_, asc.extSampleRate = br.readSampleRate()
+		asc.objectType = br.readObjectType()
+		if br.overflow {
+			return asc, errShortConfig
+		}
+		return asc, nil
+	}
+
+	if br.skipGASpecificConfig(asc.objectType, asc.channelConfig) {
+		br.readSyncExtension(&asc)
+	}
+	return asc, nil
+}
+
+// skipGASpecificConfig skips the GASpecificConfig of general audio object
+// types. It reports false if the config is absent, unsupported or truncated,
+// in which case nothing follows that can be parsed.
+func (br *bitReader) skipGASpecificConfig(objectType, channelConfig uint8) bool {
+	switch objectType {
+	case 1, 2, 3, 4, 6, 7, 17, 19, 20, 21, 22, 23:
+	default:
+		return false
+	}
+	if channelConfig == 0 {
+		// A program config element follows; not needed for SBR/PS detection
+		return false
+	}
+
+	br.read(1) // frameLengthFlag
+	if br.read(1) == 1 {
+		br.read(14) // coreCoderDelay
+	}
+	extensionFlag := br.read(1)
+	if objectType == 6 || objectType == 20 {
+		br.read(3) // layerNr
+	}
+	if extensionFlag == 1 {
+		switch objectType {
+		case 22:
+			br.read(16) // numOfSubFrame, layer_length
+		case 17, 19, 20, 23:
+			br.read(3) // resilience flags
+		}
+		br.read(1) // extensionFlag3
+	}
+	return !br.overflow
+}
+
+// readSyncExtension parses backward compatible SBR and PS signaling after
+// the decoder-specific config. Missing or unknown extensions are ignored.
+func (br *bitReader) readSyncExtension(asc *audioSpecificConfig) {
+	if br.remaining() < 16 || br.read(11) != syncExtensionSBR {
+		return
+	}
+	if br.readObjectType() != objectTypeSBR || br.read(1) == 0 {
+		return
+	}
+	_, rate := br.readSampleRate()
+	if br.overflow {
+		return
+	}
+	asc.sbr = true
+	asc.extSampleRate = rate
+
+	if br.remaining() >= 12 && br.read(11) == syncExtensionPS && br.read(1) == 1 {
+		asc.ps = true
+	}
+}
+
+// checkSampleRate returns a [*SampleRateIndexError] if the config signals a
+// reserved sampling frequency index.
+func (asc audioSpecificConfig) checkSampleRate() error {
+	if asc.sampleRate == 0 && asc.samplingFreqIndex != sampleRateIndexExplicit {
+		return &SampleRateIndexError{Index: asc.samplingFreqIndex}
+	}
+	return nil
+}
+
+// outputSampleRate returns the sample rate of the decoded output: the SBR
+// rate when SBR is signaled, otherwise the core rate.
+func (asc audioSpecificConfig) outputSampleRate() uint32 {
+	if asc.sbr && asc.extSampleRate != 0 {
+		return asc.extSampleRate
+	}
+	return asc.sampleRate
+}
+
+// profileObjectType returns the object type naming the profile: 29 with PS,
+// 5 with SBR, otherwise the core object type.
+func (asc audioSpecificConfig) profileObjectType() uint8 {
+	switch {
+	case asc.ps:
+		return objectTypePS
+	case asc.sbr:
+		return objectTypeSBR
+	default:
+		return asc.objectType
+	}
+}
+
+// readObjectType reads an audio object type, with the escape for types
+// above 30.
+func (br *bitReader) readObjectType() uint8 {
+	objectType := uint8(br.read(5)) //nolint:gosec // 5 bits
+	if objectType == 31 {
+		objectType = 32 + uint8(br.read(6)) //nolint:gosec // 6 bits
+	}
+	return objectType
+}
+
+// readSampleRate reads a sampling frequency index and returns it with its
+// rate, read explicitly for index 15.
+func (br *bitReader) readSampleRate() (uint8, uint32) {
+	index := uint8(br.read(4)) //nolint:gosec // 4 bits
+	if index == sampleRateIndexExplicit {
+		return index, br.read(24)
+	}
+	return index, adtsSampleRates[index]
+}
+
+// withSampleRateIndex returns a copy of config with the sampling frequency
+// index replaced by index. The config must not use the explicit frequency
+// escape.
+func withSampleRateIndex(config []byte, index uint8) []byte {
+	pos := 5
+	if config[0]>>3 == 31 {
+		pos = 11 // escaped object type
+	}
+	out := append([]byte(nil), config...)
+	for i := range 4 {
+		bit := pos + i
+		mask := byte(0x80) >> (bit % 8)
+		if index&(0x08>>i) != 0 {
+			out[bit/8] |= mask
+		} else {
+			out[bit/8] &^= mask
+		}
+	}
+	return out
+}
+
+// bitReader reads big-endian bit fields from a byte slice.
+type bitReader struct {
+	data     []byte
+	pos      int // bit position
+	overflow bool
+}
+
+// read returns the next n bits (n <= 32). Reading past the end sets overflow
+// and returns zero bits.
+func (br *bitReader) read(n int) uint32 {
+	var v uint32
+	for range n {
+		v <<= 1
+		byteIndex := br.pos / 8
+		if byteIndex >= len(br.data) {
+			br.overflow = true
+		} else {
+			v |= uint32(br.data[byteIndex]>>(7-br.pos%8)) & 1
+		}
+		br.pos++
+	}
+	return v
+}
+
+// remaining returns the number of unread bits.
+func (br *bitReader) remaining() int {
+	return max(len(br.data)*8-br.pos, 0)
+}
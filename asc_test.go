@@ -0,0 +1,135 @@
+package faad2
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestChannelCount(t *testing.T) {
+	tests := []struct {
+		config   uint8
+		channels uint8
+		err      error
+	}{
+		{0, 0, nil},
+		{1, 1, nil},
+		{2, 2, nil},
+		{6, 6, nil},
+		{7, 8, nil},
+		{8, 0, ErrInvalidChannelConfig},
+		{15, 0, ErrInvalidChannelConfig},
+	}
+
+	for _, tt := range tests {
+		channels, err := channelCount(tt.config)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("channelCount(%d) error = %v, want %v", tt.config, err, tt.err)
+		}
+		if channels != tt.channels {
+			t.Errorf("channelCount(%d) = %d, want %d", tt.config, channels, tt.channels)
+		}
+	}
+}
+
+func TestParseAudioSpecificConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config []byte
+		want   audioSpecificConfig
+	}{
+		{"AAC-LC 44.1kHz stereo", []byte{0x12, 0x10}, audioSpecificConfig{objectType: 2, samplingFreqIndex: 4, sampleRate: 44100, channelConfig: 2}},
+		{"AAC-LC 48kHz 7.1", []byte{0x11, 0xB8}, audioSpecificConfig{objectType: 2, samplingFreqIndex: 3, sampleRate: 48000, channelConfig: 7}},
+		{"explicit rate", []byte{0x17, 0x80, 0x5D, 0xC0, 0x10}, audioSpecificConfig{objectType: 2, samplingFreqIndex: 15, sampleRate: 48000, channelConfig: 2}},
+		{"explicit SBR", []byte{0x2B, 0x11, 0x88, 0x00}, audioSpecificConfig{
+			objectType: 2, samplingFreqIndex: 6, sampleRate: 24000, channelConfig: 2, sbr: true, extSampleRate: 48000,
+		}},
+		{"explicit PS", []byte{0xEB, 0x09, 0x88, 0x00}, audioSpecificConfig{
+			objectType: 2, samplingFreqIndex: 6, sampleRate: 24000, channelConfig: 1, sbr: true, ps: true, extSampleRate: 48000,
+		}},
+		{"backward compatible SBR", []byte{0x13, 0x10, 0x56, 0xE5, 0x98}, audioSpecificConfig{
+			objectType: 2, samplingFreqIndex: 6, sampleRate: 24000, channelConfig: 2, sbr: true, extSampleRate: 48000,
+		}},
+		{"backward compatible PS", []byte{0x13, 0x08, 0x56, 0xE5, 0x9D, 0x48, 0x80}, audioSpecificConfig{
+			objectType: 2, samplingFreqIndex: 6, sampleRate: 24000, channelConfig: 1, sbr: true, ps: true, extSampleRate: 48000,
+		}},
+		{"unknown sync extension", []byte{0x13, 0x10, 0x12, 0x34}, audioSpecificConfig{
+			objectType: 2, samplingFreqIndex: 6, sampleRate: 24000, channelConfig: 2,
+		}},
+		{"escaped object type", []byte{0xF8, 0x08, 0x40}, audioSpecificConfig{objectType: 32, samplingFreqIndex: 4, sampleRate: 44100, channelConfig: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asc, err := parseAudioSpecificConfig(tt.config)
+			if err != nil {
+				t.Fatalf("parseAudioSpecificConfig failed: %v", err)
+			}
+			if asc != tt.want {
+				t.Errorf("got %+v, want %+v", asc, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseAudioSpecificConfig([]byte{0x12}); err == nil {
+		t.Error("expected error for truncated config")
+	}
+}
+
+func TestCheckSampleRate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config []byte
+		index  int // reported index, or -1 for no error
+	}{
+		{"44.1kHz", []byte{0x12, 0x10}, -1},
+		{"7350Hz", []byte{0x16, 0x10}, -1},
+		{"reserved 13", []byte{0x16, 0x90}, 13},
+		{"reserved 14", []byte{0x17, 0x10}, 14},
+		{"explicit rate", []byte{0x17, 0x80, 0x5D, 0xC0, 0x10}, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asc, err := parseAudioSpecificConfig(tt.config)
+			if err != nil {
+				t.Fatalf("parseAudioSpecificConfig failed: %v", err)
+			}
+			err = asc.checkSampleRate()
+			if tt.index < 0 {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			var indexErr *SampleRateIndexError
+			if !errors.As(err, &indexErr) || int(indexErr.Index) != tt.index {
+				t.Errorf("expected SampleRateIndexError for index %d, got %v", tt.index, err)
+			}
+		})
+	}
+}
+
+func TestWithSampleRateIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		config []byte
+		want   []byte
+	}{
+		{"AAC-LC", []byte{0x16, 0x10}, []byte{0x15, 0x90}},
+		{"escaped object type", []byte{0xF8, 0x18, 0x40}, []byte{0xF8, 0x16, 0x40}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := append([]byte(nil), tt.config...)
+			got := withSampleRateIndex(config, sampleRateIndex8000)
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("got % X, want % X", got, tt.want)
+			}
+			if !bytes.Equal(config, tt.config) {
+				t.Error("config was modified")
+			}
+		})
+	}
+}
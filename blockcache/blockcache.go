@@ -0,0 +1,240 @@
+// Package blockcache provides a caching [io.ReadSeeker] for remote or slow sources.
+//
+// The cache holds fixed-size blocks in LRU order and is tuned for the access
+// pattern of audio containers: headers are read once near the start of the
+// file, then audio data is read mostly sequentially. The first block is pinned
+// so that header re-reads after seeking never hit the source, and sequential
+// misses fetch several blocks in a single source read.
+//
+//	f, _ := os.Open("audio.aac")
+//	cached, _ := blockcache.New(f)
+//	reader, _ := faad2.OpenADTS(ctx, cached)
+package blockcache
+
+import (
+	"container/list"
+	"errors"
+	"io"
+)
+
+const (
+	// DefaultBlockSize is the default cache block size in bytes.
+	DefaultBlockSize = 64 * 1024
+
+	// DefaultMaxBlocks is the default number of cached blocks.
+	DefaultMaxBlocks = 32
+
+	// DefaultReadAhead is the default number of extra blocks fetched on a
+	// sequential cache miss.
+	DefaultReadAhead = 3
+)
+
+// ErrInvalidSeek is returned when seeking to a negative position.
+var ErrInvalidSeek = errors.New("blockcache: invalid seek position")
+
+// Reader is a caching [io.ReadSeeker] over an [io.ReaderAt].
+//
+// A Reader is not safe for concurrent use.
+type Reader struct {
+	src       io.ReaderAt
+	size      int64
+	offset    int64
+	blockSize int64
+	maxBlocks int
+	readAhead int
+
+	blocks map[int64]*list.Element
+	lru    *list.List // of *block, most recently used at front
+	head   []byte     // pinned first block
+
+	lastMiss int64
+}
+
+type block struct {
+	index int64
+	data  []byte
+}
+
+// Option configures a [Reader].
+type Option func(*Reader)
+
+// WithBlockSize sets the cache block size in bytes.
+func WithBlockSize(size int) Option {
+	return func(r *Reader) {
+		if size > 0 {
+			r.blockSize = int64(size)
+		}
+	}
+}
+
+// WithMaxBlocks sets the maximum number of cached blocks, excluding the
+// pinned first block.
+func WithMaxBlocks(n int) Option {
+	return func(r *Reader) {
+		if n > 0 {
+			r.maxBlocks = n
+		}
+	}
+}
+
+// WithReadAhead sets the number of extra blocks fetched when a cache miss
+// directly follows the previous one. Zero disables read-ahead.
+func WithReadAhead(n int) Option {
+	return func(r *Reader) {
+		if n >= 0 {
+			r.readAhead = n
+		}
+	}
+}
+
+// New wraps an [io.ReadSeeker] with a block cache.
+//
+// The source size is determined by seeking to its end. The returned Reader
+// takes over positioning of src; src should not be used directly afterwards.
+func New(src io.ReadSeeker, opts ...Option) (*Reader, error) {
+	size, err := src.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	return NewReaderAt(&seekReaderAt{src: src}, size, opts...), nil
+}
+
+// NewReaderAt wraps an [io.ReaderAt] of the given size with a block cache.
+func NewReaderAt(src io.ReaderAt, size int64, opts ...Option) *Reader {
+	r := &Reader{
+		src:       src,
+		size:      size,
+		blockSize: DefaultBlockSize,
+		maxBlocks: DefaultMaxBlocks,
+		readAhead: DefaultReadAhead,
+		blocks:    make(map[int64]*list.Element),
+		lru:       list.New(),
+		lastMiss:  -2,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Size returns the total size of the source in bytes.
+func (r *Reader) Size() int64 {
+	return r.size
+}
+
+// Read reads up to len(p) bytes from the current position.
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	total := 0
+	for total < len(p) && r.offset < r.size {
+		index := r.offset / r.blockSize
+		data, err := r.block(index)
+		if err != nil {
+			return total, err
+		}
+
+		start := r.offset - index*r.blockSize
+		if start >= int64(len(data)) {
+			return total, io.ErrUnexpectedEOF
+		}
+		n := copy(p[total:], data[start:])
+		total += n
+		r.offset += int64(n)
+	}
+
+	return total, nil
+}
+
+// Seek sets the position for the next Read, following [io.Seeker] semantics.
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = r.offset + offset
+	case io.SeekEnd:
+		pos = r.size + offset
+	default:
+		return 0, ErrInvalidSeek
+	}
+
+	if pos < 0 {
+		return 0, ErrInvalidSeek
+	}
+	r.offset = pos
+	return pos, nil
+}
+
+// block returns the block at index, fetching it from the source on a miss.
+func (r *Reader) block(index int64) ([]byte, error) {
+	if index == 0 && r.head != nil {
+		return r.head, nil
+	}
+	if elem, ok := r.blocks[index]; ok {
+		r.lru.MoveToFront(elem)
+		b, _ := elem.Value.(*block)
+		return b.data, nil
+	}
+
+	count := int64(1)
+	if index == r.lastMiss+1 {
+		count += int64(r.readAhead)
+	}
+	r.lastMiss = index + count - 1
+
+	start := index * r.blockSize
+	length := min(count*r.blockSize, r.size-start)
+	buf := make([]byte, length)
+	n, err := r.src.ReadAt(buf, start)
+	if n < len(buf) {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	for i := range count {
+		lo := i * r.blockSize
+		if lo >= length {
+			break
+		}
+		hi := min(lo+r.blockSize, length)
+		r.store(index+i, buf[lo:hi:hi])
+	}
+
+	return buf[:min(r.blockSize, length)], nil
+}
+
+// store adds a block to the cache, evicting the least recently used one.
+func (r *Reader) store(index int64, data []byte) {
+	if index == 0 {
+		r.head = data
+		return
+	}
+	if _, ok := r.blocks[index]; ok {
+		return
+	}
+	if r.lru.Len() >= r.maxBlocks {
+		oldest := r.lru.Back()
+		b, _ := oldest.Value.(*block)
+		delete(r.blocks, b.index)
+		r.lru.Remove(oldest)
+	}
+	r.blocks[index] = r.lru.PushFront(&block{index: index, data: data})
+}
+
+// seekReaderAt adapts an io.ReadSeeker to io.ReaderAt.
+type seekReaderAt struct {
+	src io.ReadSeeker
+}
+
+func (s *seekReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if _, err := s.src.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(s.src, p)
+}
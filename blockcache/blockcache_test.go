@@ -0,0 +1,108 @@
+package blockcache
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// countingReaderAt counts ReadAt calls on the underlying source.
+type countingReaderAt struct {
+	r     *bytes.Reader
+	calls int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.calls++
+	return c.r.ReadAt(p, off)
+}
+
+func testContent(size int) []byte {
+	content := make([]byte, size)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	return content
+}
+
+func TestReadAll(t *testing.T) {
+	content := testContent(100000)
+
+	r, err := New(bytes.NewReader(content), WithBlockSize(4096))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Error("content mismatch")
+	}
+}
+
+func TestSequentialReadAhead(t *testing.T) {
+	content := testContent(64 * 1024)
+	src := &countingReaderAt{r: bytes.NewReader(content)}
+
+	r := NewReaderAt(src, int64(len(content)), WithBlockSize(1024), WithReadAhead(7))
+
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	// 64 blocks: block 0 alone, then block 1 starts a sequential run fetched
+	// 8 blocks at a time.
+	if src.calls > 10 {
+		t.Errorf("expected read-ahead to batch source reads, got %d calls", src.calls)
+	}
+}
+
+func TestHeadBlockPinned(t *testing.T) {
+	content := testContent(64 * 1024)
+	src := &countingReaderAt{r: bytes.NewReader(content)}
+
+	r := NewReaderAt(src, int64(len(content)), WithBlockSize(1024), WithMaxBlocks(2), WithReadAhead(0))
+
+	buf := make([]byte, 16)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	// Touch enough blocks to evict everything but the pinned head
+	for _, off := range []int64{10000, 20000, 30000, 40000} {
+		if _, err := r.Seek(off, io.SeekStart); err != nil {
+			t.Fatalf("Seek failed: %v", err)
+		}
+		if _, err := io.ReadFull(r, buf); err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+	}
+
+	calls := src.calls
+	if _, err := r.Seek(100, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if src.calls != calls {
+		t.Error("expected head block to stay cached")
+	}
+	if !bytes.Equal(buf, content[100:116]) {
+		t.Error("content mismatch")
+	}
+}
+
+func TestSeekInvalid(t *testing.T) {
+	r := NewReaderAt(bytes.NewReader(nil), 0)
+
+	if _, err := r.Seek(-1, io.SeekStart); !errors.Is(err, ErrInvalidSeek) {
+		t.Errorf("expected ErrInvalidSeek, got %v", err)
+	}
+	if _, err := r.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@
 // The package supports decoding AAC audio from:
 //   - M4A/MP4 container files via [OpenM4A]
 //   - Raw ADTS streams via [OpenADTS]
+//   - FLV/RTMP streams via [OpenFLV]
 //   - Direct frame decoding via [Decoder]
 //
 // Basic usage with M4A files:
@@ -28,7 +29,13 @@ package faad2
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Decoder is a low-level AAC decoder that decodes individual AAC frames.
@@ -37,40 +44,84 @@ import (
 // parsing and provide a simpler streaming interface.
 //
 // A Decoder must be initialized with [Decoder.Init] before calling [Decoder.Decode].
-// The decoder is safe for concurrent use after initialization.
+// The decoder is safe for concurrent use after initialization. Decoders share a
+// single WASM module instance whose calls are serialized; use
+// [WithDedicatedModule] to decode on several decoders in parallel.
 type Decoder struct {
 	mu          sync.Mutex
 	wctx        *wasmContext
 	decoderPtr  uint32
 	initialized bool
 	closed      bool
-	sampleRate  uint32
-	channels    uint8
+	opts        options
+
+	// frames counts Decode calls; frame is the index of the frame being
+	// decoded, or -1 outside Decode. Both are reported in WASMError.
+	frames int64
+	frame  int64
+
+	// Codec configuration, set once by Init if it could be parsed
+	asc audioSpecificConfig
+
+	// config is the AudioSpecificConfig given to FAAD2, kept for Reset
+	config []byte
+
+	// Stream format, set once by Init. Atomic so that getters never block
+	// behind a long-running Decode.
+	sampleRate atomic.Uint32
+	channels   atomic.Uint32
 }
 
 // NewDecoder creates a new AAC decoder instance.
 //
 // The decoder must be initialized with [Decoder.Init] before use.
 // Call [Decoder.Close] when done to release resources.
-func NewDecoder(ctx context.Context) (*Decoder, error) {
-	wctx, err := getWasmContext(ctx)
+func NewDecoder(ctx context.Context, opts ...Option) (*Decoder, error) {
+	o := newOptions(opts)
+
+	var (
+		wctx *wasmContext
+		err  error
+	)
+	switch {
+	case o.module != nil:
+		wctx = o.module
+	case o.dedicatedModule:
+		wctx, err = newDedicatedWasmContext(ctx, o.decodeTimeout > 0)
+	default:
+		wctx, err = getWasmContext(ctx)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	wctx.mu.Lock()
 	results, err := wctx.fnCreate.Call(ctx)
+	wctx.mu.Unlock()
 	if err != nil {
-		return nil, err
+		if o.ownsModule() {
+			_ = wctx.close(ctx)
+		}
+		return nil, &WASMError{Op: "create", Frame: -1, Err: err}
 	}
 
 	ptr := uint32(results[0]) //nolint:gosec // WASM pointers are 32-bit
 	if ptr == 0 {
+		if o.ownsModule() {
+			_ = wctx.close(ctx)
+		}
 		return nil, ErrOutOfMemory
 	}
 
+	if o.metrics != nil {
+		o.metrics.DecoderOpened()
+	}
+
 	return &Decoder{
 		wctx:       wctx,
 		decoderPtr: ptr,
+		opts:       o,
+		frame:      -1,
 	}, nil
 }
 
@@ -80,9 +131,19 @@ func NewDecoder(ctx context.Context) (*Decoder, error) {
 //   - The esds box in M4A/MP4 files
 //   - ADTS frame headers (converted via internal helper)
 //
-// Init must be called exactly once before [Decoder.Decode].
-// Returns [ErrInvalidConfig] if the configuration is nil, empty, or invalid.
+// Init must be called exactly once before [Decoder.Decode]; later calls return
+// [ErrAlreadyInitialized].
+//
+// Returns [ErrInvalidConfig] if the configuration is nil, empty, or invalid,
+// as a [*CodecError] if FAAD2 rejected it, [ErrInvalidChannelConfig] if its
+// channel configuration is above 7, or [ErrUnsupportedSampleRate] if it
+// signals a reserved sampling frequency.
 func (d *Decoder) Init(ctx context.Context, config []byte) error {
+	ctx = d.opts.context(ctx)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -90,63 +151,207 @@ func (d *Decoder) Init(ctx context.Context, config []byte) error {
 		return ErrDecoderClosed
 	}
 
+	if d.initialized {
+		return ErrAlreadyInitialized
+	}
+
 	if len(config) == 0 {
 		return ErrInvalidConfig
 	}
 
-	// Allocate memory for config
-	configPtr, err := d.wctx.malloc(ctx, uint32(len(config))) //nolint:gosec // config is small (AAC spec)
-	if err != nil {
-		return err
+	remapped7350 := false
+	if asc, err := parseAudioSpecificConfig(config); err == nil {
+		if _, err := channelCount(asc.channelConfig); err != nil {
+			return err
+		}
+		if err := asc.checkSampleRate(); err != nil {
+			return err
+		}
+		d.asc = asc
+		// FAAD2 does not know the 7350 Hz index. It shares the scalefactor
+		// band tables of 8000 Hz, so decode as 8000 Hz and scale the rate.
+		if asc.samplingFreqIndex == sampleRateIndex7350 {
+			config = withSampleRateIndex(config, sampleRateIndex8000)
+			remapped7350 = true
+		}
 	}
-	defer d.wctx.free(ctx, configPtr)
 
-	if !d.wctx.write(configPtr, config) {
-		return ErrOutOfMemory
+	d.wctx.mu.Lock()
+	defer d.wctx.mu.Unlock()
+
+	if d.wctx.isClosed() {
+		return ErrDecoderClosed
 	}
 
-	// Allocate memory for output parameters
-	sampleRatePtr, err := d.wctx.malloc(ctx, 8) // unsigned long
+	sampleRate, channels, err := d.initHandle(ctx, d.decoderPtr, config)
 	if err != nil {
 		return err
 	}
-	defer d.wctx.free(ctx, sampleRatePtr)
+	if remapped7350 {
+		sampleRate = sampleRate / 8000 * 7350
+	}
+	d.config = slices.Clone(config)
+	d.sampleRate.Store(sampleRate)
+	d.channels.Store(uint32(channels))
+	d.initialized = true
+
+	return nil
+}
 
-	channelsPtr, err := d.wctx.malloc(ctx, 1) // unsigned char
+// InitRaw initializes the decoder from stream parameters, for elementary
+// streams extracted from containers that do not carry an
+// AudioSpecificConfig. The config is built as [Decoder.Init] expects it.
+//
+// objectType is the MPEG-4 audio object type, 1 (Main) to 4 (LTP); use 2 for
+// AAC-LC. For HE-AAC, give object type 2 and the core sample rate, half the
+// output rate: FAAD2 detects SBR and doubles the rate. sampleRate must be one
+// of the standard AAC rates, and channels is the channel count (1-6, or 8 for
+// 7.1).
+//
+// Returns [ErrInvalidConfig] for an unsupported object type,
+// [ErrUnsupportedSampleRate] for a non-standard sample rate,
+// [ErrInvalidChannelConfig] for an unsupported channel count, or any error
+// from Init.
+func (d *Decoder) InitRaw(ctx context.Context, sampleRate uint32, channels, objectType uint8) error {
+	if objectType < 1 || objectType > 4 {
+		return ErrInvalidConfig
+	}
+	freqIndex, ok := sampleRateIndexFor(sampleRate)
+	if !ok {
+		return ErrUnsupportedSampleRate
+	}
+	// Channel configuration 0 needs a program config element
+	if channels == 0 {
+		return ErrInvalidChannelConfig
+	}
+	channelConfig, err := channelConfigFor(channels)
 	if err != nil {
 		return err
 	}
-	defer d.wctx.free(ctx, channelsPtr)
+	return d.Init(ctx, buildAudioSpecificConfig(objectType, freqIndex, channelConfig))
+}
+
+// InitFromADTS initializes the decoder from the header of an ADTS frame, for
+// callers that demux ADTS frames themselves, such as from an MPEG-TS stream.
+// Only the header is read. Decode takes raw AAC data, so pass the frames
+// through [ADTSPayload] before decoding them, the first one included.
+//
+// Returns the errors of [ParseADTSHeader] for an invalid header, or any error
+// from [Decoder.Init].
+func (d *Decoder) InitFromADTS(ctx context.Context, frame []byte) error {
+	if _, _, _, err := ParseADTSHeader(frame); err != nil {
+		return err
+	}
+	profile := frame[2] >> 6
+	freqIndex := (frame[2] >> 2) & 0x0F
+	channelConfig := (frame[2]&0x01)<<2 | frame[3]>>6
+	return d.Init(ctx, buildAudioSpecificConfig(profile+1, freqIndex, channelConfig))
+}
+
+// initHandle initializes the FAAD2 decoder at ptr with config and returns
+// the output format it reports. The caller must hold d.mu and d.wctx.mu.
+func (d *Decoder) initHandle(ctx context.Context, ptr uint32, config []byte) (uint32, uint8, error) {
+	// Output parameters and small configs live in the module's scratch area,
+	// so Init does not allocate in the common case.
+	scratch, err := d.wctx.scratchArea(ctx)
+	if err != nil {
+		return 0, 0, d.wasmError("init", err)
+	}
+	sampleRatePtr := scratch + scratchSampleRate // unsigned long
+	channelsPtr := scratch + scratchChannels     // unsigned char
+	configPtr := scratch + scratchConfig
+
+	if len(config) > scratchConfigSize {
+		configPtr, err = d.wctx.malloc(ctx, uint32(len(config))) //nolint:gosec // config is small (AAC spec)
+		if err != nil {
+			return 0, 0, d.wasmError("init", err)
+		}
+		defer d.wctx.free(ctx, configPtr)
+	}
+
+	if !d.wctx.write(configPtr, config) {
+		return 0, 0, ErrOutOfMemory
+	}
 
 	results, err := d.wctx.fnInit.Call(ctx,
-		uint64(d.decoderPtr),
+		uint64(ptr),
 		uint64(configPtr),
 		uint64(len(config)),
 		uint64(sampleRatePtr),
 		uint64(channelsPtr),
 	)
 	if err != nil {
-		return err
+		return 0, 0, d.wasmError("init", err)
 	}
 
 	if int32(results[0]) < 0 { //nolint:gosec // WASM returns signed status
-		return ErrInvalidConfig
+		return 0, 0, newCodecError("init", d.lastError(ctx, ptr))
 	}
 
 	// Read sample rate and channels
 	srData, ok := d.wctx.read(sampleRatePtr, 4)
 	if !ok {
-		return ErrOutOfMemory
+		return 0, 0, ErrOutOfMemory
 	}
 	chData, ok := d.wctx.read(channelsPtr, 1)
 	if !ok {
-		return ErrOutOfMemory
+		return 0, 0, ErrOutOfMemory
 	}
 
-	d.sampleRate = uint32(srData[0]) | uint32(srData[1])<<8 | uint32(srData[2])<<16 | uint32(srData[3])<<24
-	d.channels = chData[0]
-	d.initialized = true
+	sampleRate := uint32(srData[0]) | uint32(srData[1])<<8 | uint32(srData[2])<<16 | uint32(srData[3])<<24
+	return sampleRate, chData[0], nil
+}
+
+// Reset discards the decoder's internal state, such as the overlap of the
+// previous frame, so that decoding can resume at another position after
+// seeking in a container without audible glitches from the stale state.
+//
+// The decoder keeps its configuration. As after Init, the first frame decoded
+// after Reset primes the decoder and returns no samples.
+//
+// Returns [ErrNotInitialized] if [Decoder.Init] has not been called, or
+// [ErrDecoderClosed] after [Decoder.Close] or [Shutdown]. If Reset fails, the
+// decoder is left as it was.
+func (d *Decoder) Reset(ctx context.Context) error {
+	ctx = d.opts.context(ctx)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return ErrDecoderClosed
+	}
+	if !d.initialized {
+		return ErrNotInitialized
+	}
+
+	d.wctx.mu.Lock()
+	defer d.wctx.mu.Unlock()
+
+	if d.wctx.isClosed() {
+		return ErrDecoderClosed
+	}
+
+	// The module has no post-seek reset, so replace the FAAD2 decoder with a
+	// fresh one initialized from the same config.
+	results, err := d.wctx.fnCreate.Call(ctx)
+	if err != nil {
+		return d.wasmError("create", err)
+	}
+	ptr := uint32(results[0]) //nolint:gosec // WASM pointers are 32-bit
+	if ptr == 0 {
+		return ErrOutOfMemory
+	}
+	if _, _, err := d.initHandle(ctx, ptr, d.config); err != nil {
+		_, _ = d.wctx.fnDestroy.Call(ctx, uint64(ptr))
+		return err
+	}
 
+	_, _ = d.wctx.fnDestroy.Call(ctx, uint64(d.decoderPtr))
+	d.decoderPtr = ptr
 	return nil
 }
 
@@ -157,8 +362,122 @@ func (d *Decoder) Init(ctx context.Context, config []byte) error {
 // is typically 1024 or 2048 per channel, depending on the AAC profile.
 //
 // Returns [ErrNotInitialized] if [Decoder.Init] has not been called,
-// [ErrEmptyFrame] if aacFrame is empty, or [ErrDecodeFailed] on decode error.
+// [ErrEmptyFrame] if aacFrame is empty, or a [*CodecError] matching
+// [ErrDecodeFailed] with the FAAD2 error message on decode error.
+// Returns [ErrDecoderClosed] after [Decoder.Close] or [Shutdown].
+// Returns [ErrDecodeTimeout] if the decode exceeded the [WithDecodeTimeout]
+// limit; the decoder is unusable afterwards.
+// Returns [ErrLimitExceeded] if the frame or WASM memory exceeds the configured [Limits].
+// Returns the context error if ctx, or the [WithBaseContext] context used in
+// its place, is already done.
 func (d *Decoder) Decode(ctx context.Context, aacFrame []byte) ([]int16, error) {
+	return d.decodeMetered(ctx, aacFrame, nil)
+}
+
+// DecodeInto decodes a single AAC frame like [Decoder.Decode], writing the
+// samples to dst instead of a new slice, so that decode loops can reuse one
+// buffer without allocating per frame. It returns the number of samples
+// written.
+//
+// dst must hold at least [Decoder.MaxFrameSamples] samples, since the frame
+// size is only known once decoded; otherwise [io.ErrShortBuffer] is returned
+// and the frame is not decoded. Other errors are those of Decode.
+func (d *Decoder) DecodeInto(ctx context.Context, aacFrame []byte, dst []int16) (int, error) {
+	if len(dst) < d.MaxFrameSamples() {
+		return 0, io.ErrShortBuffer
+	}
+	pcm, err := d.decodeMetered(ctx, aacFrame, dst)
+	return len(pcm), err
+}
+
+// MaxFrameSamples returns the largest number of interleaved samples a single
+// frame can decode to, the buffer size needed by [Decoder.DecodeInto].
+//
+// Returns 0 if the decoder has not been initialized.
+func (d *Decoder) MaxFrameSamples() int {
+	channels := d.channels.Load()
+	if channels == 0 {
+		return 0
+	}
+	return maxFrameSamples(channels)
+}
+
+// decodeMetered decodes a frame into dst, or a new slice if dst is nil, and
+// reports it to the metrics set with [WithMetrics].
+func (d *Decoder) decodeMetered(ctx context.Context, aacFrame []byte, dst []int16) ([]int16, error) {
+	if d.opts.metrics == nil {
+		return d.decode(ctx, aacFrame, dst)
+	}
+
+	start := time.Now()
+	pcm, err := d.decode(ctx, aacFrame, dst)
+	if err != nil {
+		d.opts.metrics.DecodeFailed(err)
+	} else {
+		d.opts.metrics.FrameDecoded(time.Since(start), len(pcm))
+	}
+	return pcm, err
+}
+
+// FrameInfo describes a frame decoded with [Decoder.DecodeFrame], for
+// debugging streams whose signaling does not match their content.
+//
+// The FAAD2 module does not report the bytes consumed per frame: a frame is
+// always consumed whole. Decode failures are returned as a [*CodecError]
+// carrying the FAAD2 error code.
+type FrameInfo struct {
+	// Samples is the number of samples produced, all channels included. It
+	// is 0 for the first frame, while the decoder primes.
+	Samples int
+
+	// Channels and SampleRate are the format of the decoded samples.
+	Channels   uint8
+	SampleRate uint32
+
+	// ObjectType is the core audio object type signaled in the config, such
+	// as 2 for AAC-LC, or 0 if the config could not be parsed.
+	ObjectType uint8
+
+	// SBR reports whether the frame was decoded with spectral band
+	// replication (HE-AAC), detected from the output length, which is twice
+	// the core frame length. FAAD2 applies SBR to every stream with a core
+	// rate of 24 kHz or less, whether or not it is signaled.
+	SBR bool
+
+	// SignaledSBR and SignaledPS report whether the config explicitly
+	// signals SBR and parametric stereo (HE-AAC v2).
+	SignaledSBR bool
+	SignaledPS  bool
+}
+
+// DecodeFrame decodes a single AAC frame like [Decoder.Decode] and also
+// returns a description of the frame.
+func (d *Decoder) DecodeFrame(ctx context.Context, aacFrame []byte) ([]int16, FrameInfo, error) {
+	pcm, err := d.Decode(ctx, aacFrame)
+	if err != nil {
+		return nil, FrameInfo{}, err
+	}
+
+	info := FrameInfo{
+		Samples:     len(pcm),
+		Channels:    d.Channels(),
+		SampleRate:  d.SampleRate(),
+		ObjectType:  d.asc.objectType,
+		SignaledSBR: d.asc.sbr,
+		SignaledPS:  d.asc.ps,
+	}
+	if info.Channels > 0 {
+		info.SBR = len(pcm)/int(info.Channels) == 2*coreFrameLength
+	}
+	return pcm, info, nil
+}
+
+func (d *Decoder) decode(ctx context.Context, aacFrame []byte, dst []int16) ([]int16, error) {
+	ctx = d.opts.context(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -174,14 +493,53 @@ func (d *Decoder) Decode(ctx context.Context, aacFrame []byte) ([]int16, error)
 		return nil, ErrEmptyFrame
 	}
 
-	if d.channels == 0 {
+	if limit := d.opts.limits.MaxFrameSize; limit > 0 && len(aacFrame) > limit {
+		return nil, ErrLimitExceeded
+	}
+
+	channels := d.channels.Load()
+	if channels == 0 {
 		return nil, ErrInvalidConfig
 	}
 
+	d.wctx.mu.Lock()
+	defer d.wctx.mu.Unlock()
+
+	if d.wctx.isClosed() {
+		return nil, ErrDecoderClosed
+	}
+
+	d.frame = d.frames
+	d.frames++
+	defer func() { d.frame = -1 }()
+
+	timeout := d.opts.decodeTimeout
+	if timeout <= 0 {
+		return d.decodeWASM(ctx, aacFrame, channels, dst)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	pcm, err := d.decodeWASM(callCtx, aacFrame, channels, dst)
+	if err != nil && callCtx.Err() != nil {
+		// The runtime closed the module to abort execution
+		d.wctx.closed.Store(true)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, ErrDecodeTimeout
+	}
+	return pcm, err
+}
+
+// decodeWASM decodes one frame in the WASM module. The caller must hold d.mu
+// and d.wctx.mu.
+func (d *Decoder) decodeWASM(ctx context.Context, aacFrame []byte, channels uint32, dst []int16) ([]int16, error) {
 	// Allocate input buffer
 	inputPtr, err := d.wctx.malloc(ctx, uint32(len(aacFrame))) //nolint:gosec // frame size is bounded by AAC spec
 	if err != nil {
-		return nil, err
+		return nil, d.wasmError("decode", err)
 	}
 	defer d.wctx.free(ctx, inputPtr)
 
@@ -189,11 +547,11 @@ func (d *Decoder) Decode(ctx context.Context, aacFrame []byte) ([]int16, error)
 		return nil, ErrOutOfMemory
 	}
 
-	// Allocate output buffer (max samples per frame: 2048 * channels * 2 bytes)
-	maxSamples := 2048 * int(d.channels)
+	// Allocate output buffer (2 bytes per sample)
+	maxSamples := maxFrameSamples(channels)
 	outputPtr, err := d.wctx.malloc(ctx, uint32(maxSamples*2)) //nolint:gosec // bounded by AAC frame size
 	if err != nil {
-		return nil, err
+		return nil, d.wasmError("decode", err)
 	}
 	defer d.wctx.free(ctx, outputPtr)
 
@@ -206,12 +564,17 @@ func (d *Decoder) Decode(ctx context.Context, aacFrame []byte) ([]int16, error)
 		uint64(maxSamples*2), //nolint:gosec // bounded by AAC frame size
 	)
 	if err != nil {
-		return nil, err
+		return nil, d.wasmError("decode", err)
 	}
 
+	// The shim copies at most maxSamples samples into the output buffer
 	numSamples := int32(results[0]) //nolint:gosec // WASM returns signed sample count
 	if numSamples < 0 {
-		return nil, ErrDecodeFailed
+		return nil, newCodecError("decode", d.lastError(ctx, d.decoderPtr))
+	}
+
+	if limit := d.opts.limits.MaxDecodeMemory; limit > 0 && d.wctx.module.Memory().Size() > limit {
+		return nil, ErrLimitExceeded
 	}
 
 	// Read PCM output
@@ -220,37 +583,108 @@ func (d *Decoder) Decode(ctx context.Context, aacFrame []byte) ([]int16, error)
 		return nil, ErrOutOfMemory
 	}
 
-	pcm := make([]int16, numSamples)
-	for i := range pcm {
-		// Build uint16 from little-endian bytes, then reinterpret as int16
-		pcm[i] = int16(uint16(pcmBytes[i*2]) | uint16(pcmBytes[i*2+1])<<8) //nolint:gosec // intentional bit reinterpretation
+	if dst == nil {
+		dst = make([]int16, numSamples)
 	}
+	pcm := dst[:numSamples]
+	decodePCM16(pcm, pcmBytes)
 
 	return pcm, nil
 }
 
+// wasmError wraps an error from a call into the WASM module with the decoder
+// state. ErrOutOfMemory, reported by the allocator rather than the runtime,
+// is returned unchanged. The caller must hold d.mu.
+func (d *Decoder) wasmError(op string, err error) error {
+	if errors.Is(err, ErrOutOfMemory) {
+		return err
+	}
+	return &WASMError{Op: op, Frame: d.frame, Initialized: d.initialized, Err: err}
+}
+
+// maxErrorLength is the size of the module's error message buffer.
+const maxErrorLength = 256
+
+// lastError returns the message of the last failure of the FAAD2 decoder at
+// ptr, or "" if it cannot be read. The caller must hold d.wctx.mu.
+func (d *Decoder) lastError(ctx context.Context, ptr uint32) string {
+	results, err := d.wctx.fnGetError.Call(ctx, uint64(ptr))
+	if err != nil {
+		return ""
+	}
+	return d.wctx.readString(uint32(results[0]), maxErrorLength) //nolint:gosec // WASM pointers are 32-bit
+}
+
+// maxSamplesPerChannel is the largest number of samples FAAD2 outputs per
+// channel for one frame: a 1024-sample core frame doubled by SBR upsampling.
+const maxSamplesPerChannel = 2048
+
+// maxFrameSamples returns the output buffer size, in samples, needed to hold
+// any frame decoded with the given initial channel count.
+//
+// Parametric stereo (HE-AAC v2) turns a mono stream into stereo output, so at
+// least two channels are always provisioned.
+func maxFrameSamples(channels uint32) int {
+	return maxSamplesPerChannel * int(max(channels, 2))
+}
+
 // SampleRate returns the audio sample rate in Hz (e.g., 44100, 48000).
 //
 // Returns 0 if the decoder has not been initialized.
+// SampleRate does not block while another goroutine is decoding.
 func (d *Decoder) SampleRate() uint32 {
-	d.mu.Lock()
-	defer d.mu.Unlock()
-	return d.sampleRate
+	return d.sampleRate.Load()
 }
 
 // Channels returns the number of audio channels (1 for mono, 2 for stereo).
 //
 // Returns 0 if the decoder has not been initialized.
+// Channels does not block while another goroutine is decoding.
 func (d *Decoder) Channels() uint8 {
+	return uint8(d.channels.Load()) //nolint:gosec // stored from an unsigned char
+}
+
+// Latency returns the delay of the decoder output, in samples per channel at
+// the output sample rate, for aligning decoded audio with video decoded
+// elsewhere: audio decoded from a frame stamped t starts playing at t plus
+// this delay.
+//
+// FAAD2 withholds the first frame while its filter banks fill, so the delay
+// is one output frame: 1024 samples, or 2048 when SBR (HE-AAC) doubles the
+// output rate. Returns 0 if the decoder has not been initialized.
+// Latency does not block while another goroutine is decoding.
+func (d *Decoder) Latency() int {
+	rate := d.sampleRate.Load()
+	if rate == 0 {
+		return 0
+	}
+	// Init sets asc before publishing the rate
+	if core := d.asc.sampleRate; core > 0 && rate >= 2*core {
+		return 2 * coreFrameLength
+	}
+	return coreFrameLength
+}
+
+// String summarizes the decoder state for logging, for example
+// "faad2.Decoder(44100 Hz, 2 ch, 120 frames)".
+func (d *Decoder) String() string {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	return d.channels
+
+	switch {
+	case d.closed:
+		return "faad2.Decoder(closed)"
+	case !d.initialized:
+		return "faad2.Decoder(uninitialized)"
+	}
+	return fmt.Sprintf("faad2.Decoder(%d Hz, %d ch, %d frames)", d.SampleRate(), d.Channels(), d.frames)
 }
 
 // Close releases decoder resources.
 //
 // After Close is called, the decoder cannot be reused.
-// It is safe to call Close multiple times; subsequent calls are no-ops.
+// It is safe to call Close multiple times, concurrently with other methods,
+// and after [Shutdown]; subsequent calls are no-ops.
 func (d *Decoder) Close(ctx context.Context) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -259,11 +693,27 @@ func (d *Decoder) Close(ctx context.Context) error {
 		return nil
 	}
 
+	d.closed = true
+	if d.opts.metrics != nil {
+		d.opts.metrics.DecoderClosed()
+	}
+
+	d.wctx.mu.Lock()
+	defer d.wctx.mu.Unlock()
+
+	// After Shutdown the runtime, and the decoder with it, is already gone.
+	if d.wctx.isClosed() {
+		d.decoderPtr = 0
+		return nil
+	}
+
 	if d.decoderPtr != 0 {
 		_, _ = d.wctx.fnDestroy.Call(ctx, uint64(d.decoderPtr))
 		d.decoderPtr = 0
 	}
 
-	d.closed = true
+	if d.opts.ownsModule() {
+		return d.wctx.close(ctx)
+	}
 	return nil
 }
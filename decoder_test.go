@@ -3,7 +3,12 @@ package faad2
 import (
 	"context"
 	"errors"
+	"io"
+	"os"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewDecoder(t *testing.T) {
@@ -38,6 +43,72 @@ func TestDecoderInitWithoutConfig(t *testing.T) {
 	}
 }
 
+func TestDecoderInitTwice(t *testing.T) {
+	ctx := context.Background()
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(ctx)
+
+	if err := dec.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	err = dec.Init(ctx, []byte{0x12, 0x10})
+	if !errors.Is(err, ErrAlreadyInitialized) {
+		t.Errorf("expected ErrAlreadyInitialized, got %v", err)
+	}
+}
+
+func TestDecoderInitInvalidChannelConfig(t *testing.T) {
+	ctx := context.Background()
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(ctx)
+
+	// AAC-LC, 44.1 kHz, channelConfig 8 (reserved)
+	err = dec.Init(ctx, []byte{0x12, 0x40})
+	if !errors.Is(err, ErrInvalidChannelConfig) {
+		t.Errorf("expected ErrInvalidChannelConfig, got %v", err)
+	}
+}
+
+func TestDecoderInitReservedSampleRate(t *testing.T) {
+	ctx := context.Background()
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(ctx)
+
+	// AAC-LC, sampling frequency index 13 (reserved), stereo
+	err = dec.Init(ctx, []byte{0x16, 0x90})
+	var indexErr *SampleRateIndexError
+	if !errors.Is(err, ErrUnsupportedSampleRate) || !errors.As(err, &indexErr) || indexErr.Index != 13 {
+		t.Errorf("expected SampleRateIndexError for index 13, got %v", err)
+	}
+}
+
+func TestDecoderInit7350(t *testing.T) {
+	ctx := context.Background()
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(ctx)
+
+	// AAC-LC, 7350 Hz, stereo; FAAD2 assumes implicit SBR and doubles it
+	if err := dec.Init(ctx, []byte{0x16, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if dec.SampleRate() != 14700 {
+		t.Errorf("expected sample rate 14700, got %d", dec.SampleRate())
+	}
+}
+
 func TestDecoderDecodeWithoutInit(t *testing.T) {
 	ctx := context.Background()
 	dec, err := NewDecoder(ctx)
@@ -150,6 +221,72 @@ func TestShutdownAndReinit(t *testing.T) {
 	}
 }
 
+func TestDecoderUseAfterShutdown(t *testing.T) {
+	ctx := context.Background()
+
+	shared, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	if err := shared.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	dedicated, err := NewDecoder(ctx, WithDedicatedModule())
+	if err != nil {
+		t.Fatalf("NewDecoder with dedicated module failed: %v", err)
+	}
+
+	if err := Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if _, err := shared.Decode(ctx, silentStereoFrame); !errors.Is(err, ErrDecoderClosed) {
+		t.Errorf("Decode after Shutdown: expected ErrDecoderClosed, got %v", err)
+	}
+	if err := dedicated.Init(ctx, []byte{0x12, 0x10}); !errors.Is(err, ErrDecoderClosed) {
+		t.Errorf("Init after Shutdown: expected ErrDecoderClosed, got %v", err)
+	}
+	if err := shared.Close(ctx); err != nil {
+		t.Errorf("Close after Shutdown failed: %v", err)
+	}
+	if err := dedicated.Close(ctx); err != nil {
+		t.Errorf("Close of dedicated decoder after Shutdown failed: %v", err)
+	}
+}
+
+func TestDecoderConcurrentCloseDecode(t *testing.T) {
+	ctx := context.Background()
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	if err := dec.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for {
+				_, err := dec.Decode(ctx, silentStereoFrame)
+				if errors.Is(err, ErrDecoderClosed) {
+					return
+				}
+				if err != nil {
+					t.Errorf("Decode failed: %v", err)
+					return
+				}
+			}
+		})
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if err := dec.Close(ctx); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	wg.Wait()
+}
+
 func TestContextCancellation(t *testing.T) {
 	// Create a cancelled context
 	ctx, cancel := context.WithCancel(context.Background())
@@ -164,3 +301,667 @@ func TestContextCancellation(t *testing.T) {
 		t.Logf("NewDecoder with cancelled context returned: %v", err)
 	}
 }
+
+func TestDecoderLimits(t *testing.T) {
+	ctx := context.Background()
+
+	dec, err := NewDecoder(ctx, WithLimits(Limits{MaxFrameSize: 4}))
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(ctx)
+
+	if err := dec.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	_, err = dec.Decode(ctx, silentStereoFrame)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected ErrLimitExceeded for oversized frame, got %v", err)
+	}
+
+	memDec, err := NewDecoder(ctx, WithLimits(Limits{MaxDecodeMemory: 1}))
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer memDec.Close(ctx)
+	if !memDec.opts.ownsModule() {
+		t.Error("expected a memory limit to give the decoder its own module")
+	}
+
+	if err := memDec.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	_, err = memDec.Decode(ctx, silentStereoFrame)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected ErrLimitExceeded for memory limit, got %v", err)
+	}
+}
+
+func TestDecoderInitUsesScratch(t *testing.T) {
+	ctx := context.Background()
+
+	var scratch uint32
+	for i := range 20 {
+		dec, err := NewDecoder(ctx)
+		if err != nil {
+			t.Fatalf("NewDecoder failed: %v", err)
+		}
+		if err := dec.Init(ctx, []byte{0x12, 0x10}); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		if i == 0 {
+			scratch = dec.wctx.scratch
+		} else if dec.wctx.scratch != scratch {
+			t.Errorf("expected scratch area to be reused, got %d then %d", scratch, dec.wctx.scratch)
+		}
+		dec.Close(ctx)
+	}
+
+	// Configs larger than the scratch area fall back to a temporary allocation
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(ctx)
+
+	config := make([]byte, scratchConfigSize+8)
+	config[0], config[1] = 0x12, 0x10
+	if err := dec.Init(ctx, config); err != nil {
+		t.Fatalf("Init with large config failed: %v", err)
+	}
+	if dec.SampleRate() != 44100 {
+		t.Errorf("expected sample rate 44100, got %d", dec.SampleRate())
+	}
+}
+
+func TestDecoderGettersDoNotBlock(t *testing.T) {
+	ctx := context.Background()
+
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(ctx)
+
+	if err := dec.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	// Hold the decoder lock as a long-running Decode would
+	dec.mu.Lock()
+	defer dec.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		_ = dec.SampleRate()
+		_ = dec.Channels()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("getters blocked while the decoder was busy")
+	}
+}
+
+func TestCompilationCacheDir(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	SetCompilationCacheDir(dir)
+	t.Cleanup(func() {
+		_ = Shutdown(ctx)
+		SetCompilationCacheDir("")
+	})
+
+	// Reinitialize the runtime so the cache setting takes effect
+	if err := Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	dec.Close(ctx)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) == 0 {
+		t.Error("expected compiled module to be written to the cache directory")
+	}
+
+	defaultDir, err := DefaultCompilationCacheDir()
+	if err != nil {
+		t.Skipf("no user cache dir: %v", err)
+	}
+	if defaultDir == "" {
+		t.Error("expected non-empty default cache dir")
+	}
+}
+
+func TestMaxFrameSamples(t *testing.T) {
+	tests := []struct {
+		channels uint32
+		expected int
+	}{
+		// Mono may be upmixed to stereo by parametric stereo
+		{1, 4096},
+		{2, 4096},
+		{6, 12288},
+		{8, 16384},
+	}
+
+	for _, tt := range tests {
+		if got := maxFrameSamples(tt.channels); got != tt.expected {
+			t.Errorf("maxFrameSamples(%d): expected %d, got %d", tt.channels, tt.expected, got)
+		}
+	}
+}
+
+// countingMetrics records decoder events for tests.
+type countingMetrics struct {
+	mu                    sync.Mutex
+	opened, closed        int
+	frames, samples, errs int
+}
+
+func (m *countingMetrics) DecoderOpened() {
+	m.mu.Lock()
+	m.opened++
+	m.mu.Unlock()
+}
+
+func (m *countingMetrics) DecoderClosed() {
+	m.mu.Lock()
+	m.closed++
+	m.mu.Unlock()
+}
+
+func (m *countingMetrics) DecodeFailed(error) {
+	m.mu.Lock()
+	m.errs++
+	m.mu.Unlock()
+}
+
+func (m *countingMetrics) FrameDecoded(_ time.Duration, samples int) {
+	m.mu.Lock()
+	m.frames++
+	m.samples += samples
+	m.mu.Unlock()
+}
+
+func TestDecoderMetrics(t *testing.T) {
+	ctx := context.Background()
+	m := &countingMetrics{}
+
+	dec, err := NewDecoder(ctx, WithMetrics(m))
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	if err := dec.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	total := 0
+	for range 3 {
+		pcm, err := dec.Decode(ctx, silentStereoFrame)
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		total += len(pcm)
+	}
+	_, _ = dec.Decode(ctx, nil)
+	dec.Close(ctx)
+	dec.Close(ctx)
+
+	if m.opened != 1 || m.closed != 1 {
+		t.Errorf("expected 1 open and 1 close, got %d and %d", m.opened, m.closed)
+	}
+	if m.frames != 3 || m.samples != total {
+		t.Errorf("expected 3 frames and %d samples, got %d and %d", total, m.frames, m.samples)
+	}
+	if m.errs != 1 {
+		t.Errorf("expected 1 error, got %d", m.errs)
+	}
+}
+
+func TestDecoderWASMError(t *testing.T) {
+	ctx := context.Background()
+	dec, err := NewDecoder(ctx, WithDedicatedModule())
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(ctx)
+
+	if err := dec.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if _, err := dec.Decode(ctx, silentStereoFrame); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	// Simulate a runtime failure by closing the module behind the decoder
+	if err := dec.wctx.module.Close(ctx); err != nil {
+		t.Fatalf("module Close failed: %v", err)
+	}
+
+	_, err = dec.Decode(ctx, silentStereoFrame)
+	var wasmErr *WASMError
+	if !errors.As(err, &wasmErr) {
+		t.Fatalf("expected *WASMError, got %T: %v", err, err)
+	}
+	if wasmErr.Op != "decode" || wasmErr.Frame != 1 || !wasmErr.Initialized {
+		t.Errorf("unexpected error context: %+v", wasmErr)
+	}
+	if wasmErr.Unwrap() == nil {
+		t.Error("expected wrapped runtime error")
+	}
+	if Category(err) != CategoryResource {
+		t.Errorf("expected CategoryResource, got %v", Category(err))
+	}
+}
+
+func TestDecoderDecodeTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	dec, err := NewDecoder(ctx, WithDecodeTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(ctx)
+	if err := dec.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	for range 3 {
+		if _, err := dec.Decode(ctx, silentStereoFrame); err != nil {
+			t.Fatalf("Decode within timeout failed: %v", err)
+		}
+	}
+}
+
+func TestDecoderDecodeTimeoutExceeded(t *testing.T) {
+	ctx := context.Background()
+
+	dec, err := NewDecoder(ctx, WithDecodeTimeout(time.Nanosecond))
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	if err := dec.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	_, err = dec.Decode(ctx, silentStereoFrame)
+	if !errors.Is(err, ErrDecodeTimeout) {
+		t.Fatalf("expected ErrDecodeTimeout, got %v", err)
+	}
+	if _, err := dec.Decode(ctx, silentStereoFrame); !errors.Is(err, ErrDecoderClosed) {
+		t.Errorf("Decode after timeout: expected ErrDecoderClosed, got %v", err)
+	}
+	if err := dec.Close(ctx); err != nil {
+		t.Errorf("Close after timeout failed: %v", err)
+	}
+}
+
+func TestDecoderBaseContext(t *testing.T) {
+	base, cancel := context.WithCancel(context.Background())
+
+	dec, err := NewDecoder(base, WithBaseContext(base))
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(context.Background())
+
+	if err := dec.Init(context.TODO(), []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if _, err := dec.Decode(context.Background(), silentStereoFrame); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	cancel()
+
+	if _, err := dec.Decode(context.Background(), silentStereoFrame); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from the base context, got %v", err)
+	}
+
+	// An explicit context takes precedence over the base context
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	if _, err := dec.Decode(ctx, silentStereoFrame); err != nil {
+		t.Errorf("expected explicit context to be used, got %v", err)
+	}
+}
+
+func TestDecoderDecodeFrame(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		config []byte
+		rate   uint32
+		sbr    bool
+	}{
+		{"AAC-LC", []byte{0x12, 0x10}, 44100, false},
+		{"implicit SBR", []byte{0x13, 0x90}, 44100, true}, // 22.05 kHz core
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec, err := NewDecoder(ctx)
+			if err != nil {
+				t.Fatalf("NewDecoder failed: %v", err)
+			}
+			defer dec.Close(ctx)
+			if err := dec.Init(ctx, tt.config); err != nil {
+				t.Fatalf("Init failed: %v", err)
+			}
+
+			// The first frame primes the decoder
+			_, info, err := dec.DecodeFrame(ctx, silentStereoFrame)
+			if err != nil {
+				t.Fatalf("DecodeFrame failed: %v", err)
+			}
+			if info.Samples != 0 || info.SBR {
+				t.Errorf("expected an empty priming frame, got %+v", info)
+			}
+
+			pcm, info, err := dec.DecodeFrame(ctx, silentStereoFrame)
+			if err != nil {
+				t.Fatalf("DecodeFrame failed: %v", err)
+			}
+			want := FrameInfo{
+				Samples:    len(pcm),
+				Channels:   2,
+				SampleRate: tt.rate,
+				ObjectType: 2,
+				SBR:        tt.sbr,
+			}
+			if info != want {
+				t.Errorf("expected %+v, got %+v", want, info)
+			}
+		})
+	}
+
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(ctx)
+	if _, _, err := dec.DecodeFrame(ctx, silentStereoFrame); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+}
+
+func TestDecoderDecodeInto(t *testing.T) {
+	ctx := context.Background()
+
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(ctx)
+
+	if n := dec.MaxFrameSamples(); n != 0 {
+		t.Errorf("expected 0 before Init, got %d", n)
+	}
+	if _, err := dec.DecodeInto(ctx, silentStereoFrame, nil); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+
+	if err := dec.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if n := dec.MaxFrameSamples(); n != 4096 {
+		t.Errorf("expected 4096 samples, got %d", n)
+	}
+	if _, err := dec.DecodeInto(ctx, silentStereoFrame, make([]int16, 2048)); !errors.Is(err, io.ErrShortBuffer) {
+		t.Errorf("expected io.ErrShortBuffer, got %v", err)
+	}
+
+	ref, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer ref.Close(ctx)
+	if err := ref.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	dst := make([]int16, dec.MaxFrameSamples())
+	for i := range 3 {
+		n, err := dec.DecodeInto(ctx, silentStereoFrame, dst)
+		if err != nil {
+			t.Fatalf("DecodeInto failed: %v", err)
+		}
+		want, err := ref.Decode(ctx, silentStereoFrame)
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		if !slices.Equal(dst[:n], want) {
+			t.Errorf("frame %d: DecodeInto output differs from Decode", i)
+		}
+	}
+}
+
+func TestDecoderCodecError(t *testing.T) {
+	ctx := context.Background()
+
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(ctx)
+
+	// Object type 0 is rejected by FAAD2 rather than by the config parser
+	err = dec.Init(ctx, []byte{0x00, 0x00})
+	var codecErr *CodecError
+	if !errors.As(err, &codecErr) || codecErr.Op != "init" || codecErr.Code >= 0 {
+		t.Errorf("expected init CodecError with a negative code, got %v", err)
+	}
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+
+	dec2, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec2.Close(ctx)
+	if err := dec2.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	_, err = dec2.Decode(ctx, []byte{0xFF, 0xFF, 0xFF, 0xFF})
+	if !errors.As(err, &codecErr) || codecErr.Code != 12 || codecErr.Message != "Invalid number of channels" {
+		t.Errorf("expected CodecError for an invalid channel count, got %v", err)
+	}
+	if !errors.Is(err, ErrDecodeFailed) || !IsRecoverable(err) {
+		t.Errorf("expected recoverable ErrDecodeFailed, got %v", err)
+	}
+}
+
+func TestDecoderReset(t *testing.T) {
+	ctx := context.Background()
+
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	if err := dec.Reset(ctx); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+	if err := dec.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	for range 3 {
+		if _, err := dec.Decode(ctx, silentStereoFrame); err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+	}
+	if err := dec.Reset(ctx); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+
+	// Decoding restarts as on a fresh decoder
+	pcm, err := dec.Decode(ctx, silentStereoFrame)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(pcm) != 0 {
+		t.Errorf("expected the first frame after Reset to prime, got %d samples", len(pcm))
+	}
+	pcm, err = dec.Decode(ctx, silentStereoFrame)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(pcm) != 2048 {
+		t.Errorf("expected 2048 samples, got %d", len(pcm))
+	}
+	if dec.SampleRate() != 44100 || dec.Channels() != 2 {
+		t.Errorf("expected format to be kept, got %s", dec)
+	}
+
+	dec.Close(ctx)
+	if err := dec.Reset(ctx); !errors.Is(err, ErrDecoderClosed) {
+		t.Errorf("expected ErrDecoderClosed, got %v", err)
+	}
+}
+
+func TestDecoderLatency(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		config []byte
+		want   int
+	}{
+		{"AAC-LC", []byte{0x12, 0x10}, 1024},
+		{"implicit SBR", []byte{0x13, 0x90}, 2048},
+		{"7350 Hz", []byte{0x16, 0x10}, 2048},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec, err := NewDecoder(ctx)
+			if err != nil {
+				t.Fatalf("NewDecoder failed: %v", err)
+			}
+			defer dec.Close(ctx)
+			if n := dec.Latency(); n != 0 {
+				t.Errorf("expected 0 before Init, got %d", n)
+			}
+			if err := dec.Init(ctx, tt.config); err != nil {
+				t.Fatalf("Init failed: %v", err)
+			}
+			if n := dec.Latency(); n != tt.want {
+				t.Errorf("expected %d samples, got %d", tt.want, n)
+			}
+
+			// The delay is the output withheld at the start
+			var withheld int
+			for range 2 {
+				pcm, err := dec.Decode(ctx, silentStereoFrame)
+				if err != nil {
+					t.Fatalf("Decode failed: %v", err)
+				}
+				if len(pcm) > 0 {
+					withheld = len(pcm) / int(dec.Channels())
+				}
+			}
+			if withheld != tt.want {
+				t.Errorf("expected frames of %d samples, got %d", tt.want, withheld)
+			}
+		})
+	}
+}
+
+func TestDecoderInitRaw(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		sampleRate uint32
+		channels   uint8
+		objectType uint8
+		wantRate   uint32
+		wantErr    error
+	}{
+		{"AAC-LC stereo", 44100, 2, 2, 44100, nil},
+		{"mono", 48000, 1, 2, 48000, nil},
+		{"HE-AAC core rate", 24000, 2, 2, 48000, nil},
+		{"object type", 44100, 2, 5, 0, ErrInvalidConfig},
+		{"non-standard rate", 44000, 2, 2, 0, ErrUnsupportedSampleRate},
+		{"no channels", 44100, 0, 2, 0, ErrInvalidChannelConfig},
+		{"7 channels", 44100, 7, 2, 0, ErrInvalidChannelConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec, err := NewDecoder(ctx)
+			if err != nil {
+				t.Fatalf("NewDecoder failed: %v", err)
+			}
+			defer dec.Close(ctx)
+
+			err = dec.InitRaw(ctx, tt.sampleRate, tt.channels, tt.objectType)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if dec.SampleRate() != tt.wantRate {
+				t.Errorf("expected %d Hz, got %d", tt.wantRate, dec.SampleRate())
+			}
+		})
+	}
+}
+
+func TestDecoderInitFromADTS(t *testing.T) {
+	ctx := context.Background()
+
+	stream := buildTestADTSStream(3)
+	frameLen := 7 + len(silentStereoFrame)
+
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec.Close(ctx)
+	if err := dec.InitFromADTS(ctx, stream[:frameLen]); err != nil {
+		t.Fatalf("InitFromADTS failed: %v", err)
+	}
+	if dec.SampleRate() != 44100 || dec.Channels() != 2 {
+		t.Errorf("expected 44100 Hz stereo, got %s", dec)
+	}
+
+	total := 0
+	for i := range 3 {
+		payload, err := ADTSPayload(stream[i*frameLen:])
+		if err != nil {
+			t.Fatalf("ADTSPayload failed: %v", err)
+		}
+		pcm, err := dec.Decode(ctx, payload)
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		total += len(pcm)
+	}
+	if total != 2*2048 {
+		t.Errorf("expected %d samples, got %d", 2*2048, total)
+	}
+
+	bad, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer bad.Close(ctx)
+	if err := bad.InitFromADTS(ctx, silentStereoFrame); !errors.Is(err, ErrADTSSyncNotFound) {
+		t.Errorf("expected ErrADTSSyncNotFound, got %v", err)
+	}
+}
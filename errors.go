@@ -1,6 +1,12 @@
 package faad2
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
 
 var (
 	// ErrInvalidConfig is returned when the AAC codec configuration is invalid.
@@ -15,9 +21,320 @@ var (
 	// ErrNotInitialized is returned when trying to decode without initialization.
 	ErrNotInitialized = errors.New("faad2: decoder not initialized")
 
-	// ErrDecoderClosed is returned when trying to use a closed decoder.
+	// ErrDecoderClosed is returned when trying to use a closed decoder or
+	// reader, or any decoder after [Shutdown].
 	ErrDecoderClosed = errors.New("faad2: decoder is closed")
 
+	// ErrAlreadyInitialized is returned when Init is called more than once.
+	ErrAlreadyInitialized = errors.New("faad2: decoder already initialized")
+
 	// ErrEmptyFrame is returned when trying to decode an empty AAC frame.
 	ErrEmptyFrame = errors.New("faad2: empty AAC frame")
+
+	// ErrInvalidPlaybackRate is returned when a playback rate is out of range.
+	ErrInvalidPlaybackRate = errors.New("faad2: invalid playback rate")
+
+	// ErrInvalidChannel is returned when [WithChannel] selects a channel that
+	// the stream does not have, or when a planar read is given a number of
+	// channel buffers different from the stream's channel count.
+	ErrInvalidChannel = errors.New("faad2: channel index out of range")
+
+	// ErrNotSeekable is returned when an option that reads the stream twice,
+	// such as [WithPeakNormalization], is used with a reader that cannot seek.
+	ErrNotSeekable = errors.New("faad2: reader is not seekable")
+
+	// ErrReadTimeout is returned when reading a frame from the underlying
+	// reader takes longer than the limit set with [WithReadTimeout].
+	ErrReadTimeout = errors.New("faad2: read timed out")
+
+	// ErrInvalidFrameCount is returned when a fixed read size is not positive.
+	ErrInvalidFrameCount = errors.New("faad2: invalid frame count")
+
+	// ErrTruncated is returned when a stream ends partway through a frame,
+	// typically because a download was interrupted. Use [WithAllowTruncated]
+	// to decode the complete frames before the cut instead.
+	ErrTruncated = errors.New("faad2: stream truncated")
+
+	// ErrUnsupportedCodec is returned when a stream carries audio in a codec
+	// other than AAC. The concrete error is an [*UnsupportedCodecError].
+	ErrUnsupportedCodec = errors.New("faad2: unsupported audio codec")
+
+	// ErrDecodeTimeout is returned when decoding a frame takes longer than the
+	// limit set with [WithDecodeTimeout]. The decoder cannot be used again.
+	ErrDecodeTimeout = errors.New("faad2: decode timed out")
+
+	// ErrLimitExceeded is returned when input exceeds a configured [Limits] value.
+	ErrLimitExceeded = errors.New("faad2: resource limit exceeded")
+)
+
+// UnsupportedCodecError reports the codec of an audio stream that cannot be
+// decoded. It matches [ErrUnsupportedCodec] with [errors.Is].
+type UnsupportedCodecError struct {
+	// Codec identifies the codec, such as "MP3" or a fourcc like "alac".
+	Codec string
+}
+
+func (e *UnsupportedCodecError) Error() string {
+	return "faad2: unsupported audio codec " + e.Codec
+}
+
+// Is reports whether target is [ErrUnsupportedCodec].
+func (e *UnsupportedCodecError) Is(target error) bool {
+	return target == ErrUnsupportedCodec
+}
+
+// WASMError reports a failure inside the FAAD2 WebAssembly module, such as
+// a trap or an exhausted module, with the decoder operation that hit it.
+// The underlying runtime error is available through [errors.Unwrap].
+type WASMError struct {
+	// Op is the decoder operation: "create", "init" or "decode".
+	Op string
+
+	// Frame is the index of the frame being decoded (counting every Decode
+	// call on the decoder), or -1 outside of decoding.
+	Frame int64
+
+	// Initialized reports whether the decoder had been initialized.
+	Initialized bool
+
+	Err error
+}
+
+func (e *WASMError) Error() string {
+	msg := "faad2: wasm " + e.Op + " failed"
+	if e.Frame >= 0 {
+		msg += " at frame " + strconv.FormatInt(e.Frame, 10)
+	}
+	if !e.Initialized {
+		msg += " (decoder not initialized)"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *WASMError) Unwrap() error {
+	return e.Err
+}
+
+// RangeError reports a size or count field whose value is out of range, such
+// as an ADTS frame length shorter than its own header, so that corrupt input
+// is diagnosed rather than decoded into nonsense. It matches the error of the
+// layer it was found in, such as [ErrInvalidADTS], with [errors.Is].
+type RangeError struct {
+	// Field names the field, such as "ADTS frame length".
+	Field string
+
+	// Value is the value read.
+	Value int64
+
+	Err error
+}
+
+func (e *RangeError) Error() string {
+	return e.Err.Error() + ": " + e.Field + " " + strconv.FormatInt(e.Value, 10) + " out of range"
+}
+
+func (e *RangeError) Unwrap() error {
+	return e.Err
+}
+
+// CodecError reports a failure diagnosed by FAAD2 itself, with its message,
+// so that a corrupt frame can be told apart from an unsupported stream. It
+// matches [ErrInvalidConfig] when returned by [Decoder.Init] and
+// [ErrDecodeFailed] when returned by [Decoder.Decode], with [errors.Is].
+type CodecError struct {
+	// Op is the decoder operation: "init" or "decode".
+	Op string
+
+	// Code is the FAAD2 error number, such as 12 for an invalid number of
+	// channels while decoding, or the negative status of a failed init. It
+	// is 0 if FAAD2 did not give one.
+	Code int
+
+	// Message is the FAAD2 error message. FAAD2 has none for init failures,
+	// which only report a status.
+	Message string
+}
+
+func (e *CodecError) Error() string {
+	msg := e.sentinel().Error()
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Code != 0 {
+		msg += " (code " + strconv.Itoa(e.Code) + ")"
+	}
+	return msg
+}
+
+// Is reports whether target is the sentinel error of the operation.
+func (e *CodecError) Is(target error) bool {
+	return target == e.sentinel()
+}
+
+func (e *CodecError) sentinel() error {
+	if e.Op == "init" {
+		return ErrInvalidConfig
+	}
+	return ErrDecodeFailed
+}
+
+// faad2ErrorMessages are the frame error messages of FAAD2, indexed by error
+// number. The module only reports the message, from which the number is
+// recovered.
+var faad2ErrorMessages = []string{
+	"No error",
+	"Gain control not yet implemented",
+	"Pulse coding not allowed in short blocks",
+	"Invalid huffman codebook",
+	"Scalefactor out of range",
+	"Unable to find ADTS syncword",
+	"Channel coupling not yet implemented",
+	"Channel configuration not allowed in error resilient frame",
+	"Bit error in error resilient scalefactor decoding",
+	"Error decoding huffman scalefactor (bitstream error)",
+	"Error decoding huffman codeword (bitstream error)",
+	"Non existent huffman codebook number found",
+	"Invalid number of channels",
+	"Maximum number of bitstream elements exceeded",
+	"Input data buffer too small",
+	"Array index out of range",
+	"Maximum number of scalefactor bands exceeded",
+	"Quantised value out of range",
+	"LTP lag out of range",
+	"Invalid SBR parameter decoded",
+	"SBR called without being initialised",
+	"Unexpected channel configuration change",
+	"Error in program_config_element",
+	"First SBR frame is not the same as first AAC frame",
+	"Unexpected fill element with SBR data",
+	"Not all elements were provided with SBR data",
+	"LTP decoding not available",
+	"Output data buffer too small",
+	"CRC error in DRM data",
+	"PNS not allowed in DRM data stream",
+	"No standard extension payload allowed in DRM",
+	"PCE shall be the first element in a frame",
+	"Bitstream value not allowed by specification",
+	"MAIN prediction not initialised",
+}
+
+// newCodecError builds the CodecError of op from the module's error message.
+func newCodecError(op, message string) *CodecError {
+	e := &CodecError{Op: op, Message: message}
+	if op == "init" {
+		// The module formats init failures as "Init failed with code N"
+		if code, ok := strings.CutPrefix(message, "Init failed with code "); ok {
+			e.Code, _ = strconv.Atoi(code)
+			e.Message = ""
+		}
+	} else if code := slices.Index(faad2ErrorMessages, message); code > 0 {
+		e.Code = code
+	}
+	return e
+}
+
+// ErrorCategory classifies errors returned by this package so that streaming
+// players can decide how to react to them.
+type ErrorCategory int
+
+const (
+	// CategoryUnknown is returned for nil errors and errors not produced by
+	// this package (including io.EOF and context errors).
+	CategoryUnknown ErrorCategory = iota
+
+	// CategoryContainer covers malformed stream structure, such as invalid
+	// ADTS or FLV headers or codec configuration. The stream should be aborted.
+	CategoryContainer
+
+	// CategoryBitstream covers corrupt AAC frame data. The offending frame can
+	// be skipped and decoding continued.
+	CategoryBitstream
+
+	// CategoryResource covers WASM memory exhaustion, WASM runtime failures
+	// ([*WASMError]), timeouts and decoder lifecycle errors. The operation may
+	// succeed after releasing resources, creating a new decoder, or
+	// reconnecting a stalled stream.
+	CategoryResource
+
+	// CategoryUsage covers invalid arguments and options, such as an out of
+	// range channel index or playback rate. Retrying the same call fails
+	// again.
+	CategoryUsage
 )
+
+// String returns a human-readable category name.
+func (c ErrorCategory) String() string {
+	switch c {
+	case CategoryUnknown:
+		return "unknown"
+	case CategoryContainer:
+		return "container"
+	case CategoryBitstream:
+		return "bitstream"
+	case CategoryResource:
+		return "resource"
+	case CategoryUsage:
+		return "usage"
+	}
+	return "unknown"
+}
+
+var errorCategories = []struct {
+	err      error
+	category ErrorCategory
+}{
+	{ErrInvalidADTS, CategoryContainer},
+	{ErrADTSSyncNotFound, CategoryContainer},
+	{ErrInvalidFLV, CategoryContainer},
+	{ErrFLVNoAAC, CategoryContainer},
+	{ErrUnsupportedCodec, CategoryContainer},
+	{ErrTruncated, CategoryContainer},
+	{ErrInvalidConfig, CategoryContainer},
+	{ErrInvalidChannelConfig, CategoryContainer},
+	{ErrUnsupportedSampleRate, CategoryContainer},
+	{ErrDecodeFailed, CategoryBitstream},
+	{ErrEmptyFrame, CategoryBitstream},
+	{ErrOutOfMemory, CategoryResource},
+	{ErrLimitExceeded, CategoryResource},
+	{ErrDecodeTimeout, CategoryResource},
+	{ErrNotInitialized, CategoryResource},
+	{ErrAlreadyInitialized, CategoryResource},
+	{ErrDecoderClosed, CategoryResource},
+	{ErrReadTimeout, CategoryResource},
+	{ErrInvalidChannel, CategoryUsage},
+	{ErrInvalidPlaybackRate, CategoryUsage},
+	{ErrInvalidFrameCount, CategoryUsage},
+	{ErrInvalidPCMFormat, CategoryUsage},
+	{ErrInvalidState, CategoryUsage},
+	{ErrNotSeekable, CategoryUsage},
+}
+
+// Category returns the category of err, matching wrapped errors with [errors.Is].
+func Category(err error) ErrorCategory {
+	if err == nil {
+		return CategoryUnknown
+	}
+	for _, ec := range errorCategories {
+		if errors.Is(err, ec.err) {
+			return ec.category
+		}
+	}
+	var wasmErr *WASMError
+	if errors.As(err, &wasmErr) {
+		// A call aborted by the caller's context is not a resource problem
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return CategoryUnknown
+		}
+		return CategoryResource
+	}
+	return CategoryUnknown
+}
+
+// IsRecoverable reports whether decoding can continue after err by skipping
+// the current frame. Only bitstream errors are recoverable.
+func IsRecoverable(err error) bool {
+	return Category(err) == CategoryBitstream
+}
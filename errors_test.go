@@ -0,0 +1,181 @@
+package faad2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCategory(t *testing.T) {
+	tests := []struct {
+		err         error
+		category    ErrorCategory
+		recoverable bool
+	}{
+		{nil, CategoryUnknown, false},
+		{io.EOF, CategoryUnknown, false},
+		{context.Canceled, CategoryUnknown, false},
+		{ErrInvalidADTS, CategoryContainer, false},
+		{ErrADTSSyncNotFound, CategoryContainer, false},
+		{ErrInvalidConfig, CategoryContainer, false},
+		{ErrInvalidChannelConfig, CategoryContainer, false},
+		{&SampleRateIndexError{Index: 13}, CategoryContainer, false},
+		{&RangeError{Field: "ADTS frame length", Value: 3, Err: ErrInvalidADTS}, CategoryContainer, false},
+		{ErrDecodeFailed, CategoryBitstream, true},
+		{ErrEmptyFrame, CategoryBitstream, true},
+		{fmt.Errorf("frame 12: %w", ErrDecodeFailed), CategoryBitstream, true},
+		{ErrOutOfMemory, CategoryResource, false},
+		{ErrDecoderClosed, CategoryResource, false},
+		{ErrDecodeTimeout, CategoryResource, false},
+		{&WASMError{Op: "decode", Err: errors.New("wasm error: unreachable")}, CategoryResource, false},
+		{&WASMError{Op: "decode", Err: context.Canceled}, CategoryUnknown, false},
+		{&WASMError{Op: "decode", Err: context.DeadlineExceeded}, CategoryUnknown, false},
+		{errors.New("other"), CategoryUnknown, false},
+	}
+
+	for _, tt := range tests {
+		if got := Category(tt.err); got != tt.category {
+			t.Errorf("Category(%v): expected %v, got %v", tt.err, tt.category, got)
+		}
+		if got := IsRecoverable(tt.err); got != tt.recoverable {
+			t.Errorf("IsRecoverable(%v): expected %v, got %v", tt.err, tt.recoverable, got)
+		}
+	}
+}
+
+func TestCategoryCoversPackageErrors(t *testing.T) {
+	errs := map[string]error{
+		"ErrInvalidADTS":           ErrInvalidADTS,
+		"ErrADTSSyncNotFound":      ErrADTSSyncNotFound,
+		"ErrInvalidChannelConfig":  ErrInvalidChannelConfig,
+		"ErrUnsupportedSampleRate": ErrUnsupportedSampleRate,
+		"ErrInvalidConfig":         ErrInvalidConfig,
+		"ErrDecodeFailed":          ErrDecodeFailed,
+		"ErrOutOfMemory":           ErrOutOfMemory,
+		"ErrNotInitialized":        ErrNotInitialized,
+		"ErrDecoderClosed":         ErrDecoderClosed,
+		"ErrAlreadyInitialized":    ErrAlreadyInitialized,
+		"ErrEmptyFrame":            ErrEmptyFrame,
+		"ErrInvalidPlaybackRate":   ErrInvalidPlaybackRate,
+		"ErrInvalidChannel":        ErrInvalidChannel,
+		"ErrNotSeekable":           ErrNotSeekable,
+		"ErrReadTimeout":           ErrReadTimeout,
+		"ErrInvalidFrameCount":     ErrInvalidFrameCount,
+		"ErrTruncated":             ErrTruncated,
+		"ErrUnsupportedCodec":      ErrUnsupportedCodec,
+		"ErrDecodeTimeout":         ErrDecodeTimeout,
+		"ErrLimitExceeded":         ErrLimitExceeded,
+		"ErrInvalidFLV":            ErrInvalidFLV,
+		"ErrFLVNoAAC":              ErrFLVNoAAC,
+		"ErrInvalidPCMFormat":      ErrInvalidPCMFormat,
+		"ErrInvalidState":          ErrInvalidState,
+	}
+
+	// Every exported Err variable declared in the package must be listed
+	fset := token.NewFileSet()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatalf("parsing %s: %v", name, err)
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				for _, ident := range spec.(*ast.ValueSpec).Names { //nolint:forcetypeassert // var specs
+					if _, ok := errs[ident.Name]; strings.HasPrefix(ident.Name, "Err") && !ok {
+						t.Errorf("%s (%s) is missing from this test", ident.Name, name)
+					}
+				}
+			}
+		}
+	}
+
+	for name, err := range errs {
+		if Category(err) == CategoryUnknown {
+			t.Errorf("Category(%s) is unknown", name)
+		}
+	}
+
+	if got := Category(ErrReadTimeout); got != CategoryResource {
+		t.Errorf("Category(ErrReadTimeout): expected resource, got %v", got)
+	}
+	for _, err := range []error{ErrInvalidChannel, ErrInvalidPlaybackRate, ErrInvalidFrameCount, ErrInvalidState} {
+		if got := Category(err); got != CategoryUsage {
+			t.Errorf("Category(%v): expected usage, got %v", err, got)
+		}
+	}
+}
+
+func TestWASMErrorMessage(t *testing.T) {
+	inner := errors.New("wasm error: unreachable")
+
+	err := &WASMError{Op: "decode", Frame: 12, Initialized: true, Err: inner}
+	if got, want := err.Error(), "faad2: wasm decode failed at frame 12: wasm error: unreachable"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	err = &WASMError{Op: "init", Frame: -1, Err: inner}
+	if got, want := err.Error(), "faad2: wasm init failed (decoder not initialized): wasm error: unreachable"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !errors.Is(err, inner) {
+		t.Error("expected WASMError to unwrap to the runtime error")
+	}
+
+	// Without a cause, as for a zero value
+	err = &WASMError{Op: "create", Frame: -1}
+	if got, want := err.Error(), "faad2: wasm create failed (decoder not initialized)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	_ = (&WASMError{}).Error()
+}
+
+func TestRangeErrorMessage(t *testing.T) {
+	err := &RangeError{Field: "FLV data offset", Value: 4, Err: ErrInvalidFLV}
+	if got, want := err.Error(), "faad2: invalid FLV stream: FLV data offset 4 out of range"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCodecError(t *testing.T) {
+	tests := []struct {
+		op, message string
+		code        int
+		want        string
+		is          error
+	}{
+		{"decode", "Invalid number of channels", 12, "faad2: decode failed: Invalid number of channels (code 12)", ErrDecodeFailed},
+		{"decode", "something else", 0, "faad2: decode failed: something else", ErrDecodeFailed},
+		{"decode", "", 0, "faad2: decode failed", ErrDecodeFailed},
+		{"init", "Init failed with code -1", -1, "faad2: invalid codec configuration (code -1)", ErrInvalidConfig},
+	}
+	for _, tt := range tests {
+		err := newCodecError(tt.op, tt.message)
+		if err.Code != tt.code {
+			t.Errorf("%q: expected code %d, got %d", tt.message, tt.code, err.Code)
+		}
+		if got := err.Error(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+		if !errors.Is(err, tt.is) {
+			t.Errorf("%q: expected to match %v", tt.message, tt.is)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package faad2
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ADTSFrame is a raw ADTS frame with its position in the stream, as reported
+// by [ExportADTSFrames].
+type ADTSFrame struct {
+	// Offset is the byte offset of the frame header from the start of the
+	// stream.
+	Offset int64
+
+	// Timestamp is the presentation time of the frame's first sample.
+	Timestamp time.Duration
+
+	// Data is the complete frame, header included. It is only valid until fn
+	// returns.
+	Data []byte
+}
+
+// ExportADTSFrames reads the ADTS frames of r without decoding them and calls
+// fn for each, in order. Recording the offsets and timestamps in an index
+// file next to an archived capture makes later random access into it
+// instant: seek the file to the offset of the frame preceding the wanted
+// time and open it with [OpenADTS].
+//
+// Timestamps count 1024 samples per raw data block at the rate signaled in
+// each header, so they match the decoded output of AAC-LC streams.
+//
+// Reading stops at the end of r, when fn returns an error, which is returned,
+// or when ctx is done. A frame cut short by the end of the stream returns
+// [ErrTruncated], or nil with [WithAllowTruncated]. [WithLimits] and
+// [WithBufferSize] apply as for [OpenADTS].
+func ExportADTSFrames(ctx context.Context, r io.Reader, fn func(ADTSFrame) error, opts ...Option) error {
+	ar := newADTSReader(r, newOptions(opts))
+	counter := &offsetReader{r: ar.reader}
+	ar.reader = counter
+
+	var (
+		frame     []byte
+		base      time.Duration // time at which the current rate started
+		samples   int64         // samples since base
+		rate      uint32
+		timestamp time.Duration
+	)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		header, err := ar.readHeader()
+		if err == nil {
+			var payload []byte
+			payload, err = ar.readPayload(header)
+			switch {
+			case err == nil:
+				headerSize := int(header.frameLength) - len(payload)
+				frame = append(append(frame[:0], ar.headerBuf[:headerSize]...), payload...)
+			case errors.Is(err, io.EOF):
+				err = io.ErrUnexpectedEOF // the stream ended after the header
+			}
+		}
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			if ar.opts.allowTruncated {
+				return nil
+			}
+			return ErrTruncated
+		case err != nil:
+			return err
+		}
+
+		frameRate, err := adtsSampleRate(header.samplingFreqIndex)
+		if err != nil {
+			return err
+		}
+		if frameRate != rate {
+			base = timestamp
+			samples = 0
+			rate = frameRate
+		}
+
+		err = fn(ADTSFrame{
+			Offset:    counter.n - int64(len(frame)),
+			Timestamp: timestamp,
+			Data:      frame,
+		})
+		if err != nil {
+			return err
+		}
+
+		samples += coreFrameLength * int64(header.numRawDataBlocks+1)
+		timestamp = base + samplesDuration(samples, int64(rate))
+	}
+}
+
+// offsetReader counts the bytes read through it.
+type offsetReader struct {
+	r io.Reader
+	n int64
+}
+
+func (o *offsetReader) Read(p []byte) (int, error) {
+	n, err := o.r.Read(p)
+	o.n += int64(n)
+	return n, err
+}
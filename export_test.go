@@ -0,0 +1,90 @@
+package faad2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExportADTSFrames(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(5)
+	frameLen := 7 + len(silentStereoFrame)
+
+	// Offsets must account for the read buffer in front of plain readers
+	src := &countingReader{r: bytes.NewReader(stream)}
+	var frames []ADTSFrame
+	err := ExportADTSFrames(ctx, src, func(f ADTSFrame) error {
+		f.Data = append([]byte(nil), f.Data...)
+		frames = append(frames, f)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportADTSFrames failed: %v", err)
+	}
+
+	if len(frames) != 5 {
+		t.Fatalf("expected 5 frames, got %d", len(frames))
+	}
+	for i, f := range frames {
+		offset := i * frameLen
+		if f.Offset != int64(offset) {
+			t.Errorf("frame %d: expected offset %d, got %d", i, offset, f.Offset)
+		}
+		if want := time.Duration(i) * 1024 * time.Second / 44100; f.Timestamp != want {
+			t.Errorf("frame %d: expected timestamp %v, got %v", i, want, f.Timestamp)
+		}
+		if !bytes.Equal(f.Data, stream[offset:offset+frameLen]) {
+			t.Errorf("frame %d: data does not match the stream", i)
+		}
+	}
+
+	// The offset of a frame can be used to resume decoding from it
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream[frames[2].Offset:]))
+	if err != nil {
+		t.Fatalf("OpenADTS at exported offset failed: %v", err)
+	}
+	defer reader.Close(ctx)
+	if total := readAllSamples(t, reader.Read, 4096); total != 2*2048 {
+		t.Errorf("expected %d samples from frame 2, got %d", 2*2048, total)
+	}
+}
+
+func TestExportADTSFramesErrors(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(5)
+
+	errStop := errors.New("stop")
+	var calls int
+	err := ExportADTSFrames(ctx, bytes.NewReader(stream), func(ADTSFrame) error {
+		if calls++; calls == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || calls != 2 {
+		t.Errorf("expected callback error after 2 frames, got %v after %d", err, calls)
+	}
+
+	truncated := stream[:len(stream)-3]
+	count := func(ADTSFrame) error { return nil }
+	if err := ExportADTSFrames(ctx, bytes.NewReader(truncated), count); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated, got %v", err)
+	}
+	if err := ExportADTSFrames(ctx, bytes.NewReader(truncated), count, WithAllowTruncated()); err != nil {
+		t.Errorf("expected nil with WithAllowTruncated, got %v", err)
+	}
+
+	headerOnly := stream[:len(stream)-len(silentStereoFrame)]
+	if err := ExportADTSFrames(ctx, bytes.NewReader(headerOnly), count); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated for a header without payload, got %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := ExportADTSFrames(cancelled, bytes.NewReader(stream), count); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
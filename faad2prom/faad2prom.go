@@ -0,0 +1,144 @@
+// Package faad2prom exports go-faad2 decoder metrics to Prometheus.
+//
+// A [Collector] implements both [faad2.Metrics] and [prometheus.Collector].
+// Register it once and pass it to every decoder or reader:
+//
+//	collector := faad2prom.NewCollector()
+//	prometheus.MustRegister(collector)
+//
+//	reader, err := faad2.OpenADTS(ctx, r, faad2.WithMetrics(collector))
+//
+// Frames per second is available as the rate of the frames counter, e.g.
+// rate(faad2_frames_decoded_total[1m]).
+package faad2prom
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	faad2 "github.com/llehouerou/go-faad2"
+)
+
+// Collector collects metrics from any number of decoders.
+type Collector struct {
+	decodersActive  prometheus.Gauge
+	decodersOpened  prometheus.Counter
+	framesDecoded   prometheus.Counter
+	samplesDecoded  prometheus.Counter
+	decodeDuration  prometheus.Histogram
+	decodeErrors    *prometheus.CounterVec
+	namespace       string
+	durationBuckets []float64
+}
+
+var (
+	_ faad2.Metrics        = (*Collector)(nil)
+	_ prometheus.Collector = (*Collector)(nil)
+)
+
+// Option configures a [Collector].
+type Option func(*Collector)
+
+// WithNamespace sets the metric name prefix. Defaults to "faad2".
+func WithNamespace(namespace string) Option {
+	return func(c *Collector) {
+		c.namespace = namespace
+	}
+}
+
+// WithDurationBuckets sets the histogram buckets for decode durations, in
+// seconds. The default buckets range from 10µs to about 20ms.
+func WithDurationBuckets(buckets []float64) Option {
+	return func(c *Collector) {
+		c.durationBuckets = buckets
+	}
+}
+
+// NewCollector creates a collector. Register it with a [prometheus.Registerer].
+func NewCollector(opts ...Option) *Collector {
+	c := &Collector{
+		namespace:       "faad2",
+		durationBuckets: prometheus.ExponentialBuckets(10e-6, 2, 12),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.decodersActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: c.namespace,
+		Name:      "decoders_active",
+		Help:      "Number of open decoders.",
+	})
+	c.decodersOpened = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: c.namespace,
+		Name:      "decoders_opened_total",
+		Help:      "Total number of decoders created.",
+	})
+	c.framesDecoded = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: c.namespace,
+		Name:      "frames_decoded_total",
+		Help:      "Total number of AAC frames decoded.",
+	})
+	c.samplesDecoded = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: c.namespace,
+		Name:      "samples_decoded_total",
+		Help:      "Total number of PCM samples produced, across all channels.",
+	})
+	c.decodeDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: c.namespace,
+		Name:      "decode_duration_seconds",
+		Help:      "Time spent decoding one AAC frame.",
+		Buckets:   c.durationBuckets,
+	})
+	c.decodeErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: c.namespace,
+		Name:      "decode_errors_total",
+		Help:      "Total number of failed frame decodes, by error category.",
+	}, []string{"category"})
+
+	return c
+}
+
+// DecoderOpened implements [faad2.Metrics].
+func (c *Collector) DecoderOpened() {
+	c.decodersOpened.Inc()
+	c.decodersActive.Inc()
+}
+
+// DecoderClosed implements [faad2.Metrics].
+func (c *Collector) DecoderClosed() {
+	c.decodersActive.Dec()
+}
+
+// FrameDecoded implements [faad2.Metrics].
+func (c *Collector) FrameDecoded(elapsed time.Duration, samples int) {
+	c.framesDecoded.Inc()
+	c.samplesDecoded.Add(float64(samples))
+	c.decodeDuration.Observe(elapsed.Seconds())
+}
+
+// DecodeFailed implements [faad2.Metrics].
+func (c *Collector) DecodeFailed(err error) {
+	c.decodeErrors.WithLabelValues(faad2.Category(err).String()).Inc()
+}
+
+// Describe implements [prometheus.Collector].
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.decodersActive.Describe(ch)
+	c.decodersOpened.Describe(ch)
+	c.framesDecoded.Describe(ch)
+	c.samplesDecoded.Describe(ch)
+	c.decodeDuration.Describe(ch)
+	c.decodeErrors.Describe(ch)
+}
+
+// Collect implements [prometheus.Collector].
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.decodersActive.Collect(ch)
+	c.decodersOpened.Collect(ch)
+	c.framesDecoded.Collect(ch)
+	c.samplesDecoded.Collect(ch)
+	c.decodeDuration.Collect(ch)
+	c.decodeErrors.Collect(ch)
+}
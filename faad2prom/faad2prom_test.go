@@ -0,0 +1,88 @@
+package faad2prom
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	faad2 "github.com/llehouerou/go-faad2"
+)
+
+func TestCollectorEvents(t *testing.T) {
+	c := NewCollector()
+
+	c.DecoderOpened()
+	c.DecoderOpened()
+	c.DecoderClosed()
+	c.FrameDecoded(time.Millisecond, 2048)
+	c.FrameDecoded(time.Millisecond, 2048)
+	c.DecodeFailed(faad2.ErrDecodeFailed)
+
+	expected := `
+# HELP faad2_decode_errors_total Total number of failed frame decodes, by error category.
+# TYPE faad2_decode_errors_total counter
+faad2_decode_errors_total{category="bitstream"} 1
+# HELP faad2_decoders_active Number of open decoders.
+# TYPE faad2_decoders_active gauge
+faad2_decoders_active 1
+# HELP faad2_decoders_opened_total Total number of decoders created.
+# TYPE faad2_decoders_opened_total counter
+faad2_decoders_opened_total 2
+# HELP faad2_frames_decoded_total Total number of AAC frames decoded.
+# TYPE faad2_frames_decoded_total counter
+faad2_frames_decoded_total 2
+# HELP faad2_samples_decoded_total Total number of PCM samples produced, across all channels.
+# TYPE faad2_samples_decoded_total counter
+faad2_samples_decoded_total 4096
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"faad2_decode_errors_total",
+		"faad2_decoders_active",
+		"faad2_decoders_opened_total",
+		"faad2_frames_decoded_total",
+		"faad2_samples_decoded_total",
+	)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectorWithDecoder(t *testing.T) {
+	ctx := context.Background()
+	c := NewCollector(WithNamespace("test"))
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	dec, err := faad2.NewDecoder(ctx, faad2.WithMetrics(c))
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	if err := dec.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	silentFrame := []byte{0x21, 0x00, 0x49, 0x90, 0x02, 0x19, 0x00, 0x23, 0x80}
+	for range 3 {
+		if _, err := dec.Decode(ctx, silentFrame); err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+	}
+	dec.Close(ctx)
+
+	if got := testutil.ToFloat64(c.framesDecoded); got != 3 {
+		t.Errorf("expected 3 frames, got %v", got)
+	}
+	if got := testutil.ToFloat64(c.decodersActive); got != 0 {
+		t.Errorf("expected 0 active decoders, got %v", got)
+	}
+	if n, err := testutil.GatherAndCount(reg, "test_decode_duration_seconds"); err != nil || n != 1 {
+		t.Errorf("expected decode duration histogram, got %d (%v)", n, err)
+	}
+}
@@ -0,0 +1,219 @@
+package faad2rtp
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pion/rtp"
+)
+
+var (
+	// ErrInvalidFmtp is returned by [ParseFmtp] for an fmtp line that does not
+	// describe an AAC stream in AAC-hbr or AAC-lbr mode.
+	ErrInvalidFmtp = errors.New("faad2rtp: invalid mpeg4-generic fmtp")
+
+	// ErrInvalidPayload is returned when an RTP payload does not match the
+	// AU header layout of the [Config].
+	ErrInvalidPayload = errors.New("faad2rtp: invalid RFC 3640 payload")
+)
+
+// Config describes an RFC 3640 (mpeg4-generic) AAC stream, as signaled by the
+// a=fmtp line of the SDP.
+type Config struct {
+	// AudioSpecificConfig is the decoder configuration, the hex-decoded
+	// "config" parameter.
+	AudioSpecificConfig []byte
+
+	// SizeLength, IndexLength and IndexDeltaLength are the bit widths of the
+	// AU header fields: 13, 3 and 3 in AAC-hbr mode, 6, 2 and 2 in AAC-lbr.
+	SizeLength       int
+	IndexLength      int
+	IndexDeltaLength int
+}
+
+// ParseFmtp parses the parameters of an mpeg4-generic a=fmtp line, such as
+//
+//	streamtype=5;profile-level-id=15;mode=AAC-hbr;config=1210;sizelength=13;indexlength=3;indexdeltalength=3
+//
+// The payload type prefix ("96 ") is optional and parameter names are case
+// insensitive.
+func ParseFmtp(fmtp string) (Config, error) {
+	fmtp = strings.TrimSpace(fmtp)
+	if i := strings.IndexByte(fmtp, ' '); i >= 0 && !strings.Contains(fmtp[:i], "=") {
+		fmtp = fmtp[i+1:]
+	}
+
+	var cfg Config
+	for param := range strings.SplitSeq(fmtp, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		var err error
+		switch key {
+		case "config":
+			cfg.AudioSpecificConfig, err = hex.DecodeString(value)
+		case "sizelength":
+			cfg.SizeLength, err = strconv.Atoi(value)
+		case "indexlength":
+			cfg.IndexLength, err = strconv.Atoi(value)
+		case "indexdeltalength":
+			cfg.IndexDeltaLength, err = strconv.Atoi(value)
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: %s: %w", ErrInvalidFmtp, key, err)
+		}
+	}
+
+	if len(cfg.AudioSpecificConfig) == 0 {
+		return Config{}, fmt.Errorf("%w: missing config", ErrInvalidFmtp)
+	}
+	if err := cfg.validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// validate checks that the AU header fields fit the supported layout.
+func (c Config) validate() error {
+	if c.SizeLength <= 0 || c.SizeLength > 16 ||
+		c.IndexLength < 0 || c.IndexLength > 8 ||
+		c.IndexDeltaLength < 0 || c.IndexDeltaLength > 8 {
+		return fmt.Errorf("%w: unsupported AU header layout %d/%d/%d",
+			ErrInvalidFmtp, c.SizeLength, c.IndexLength, c.IndexDeltaLength)
+	}
+	return nil
+}
+
+// Depacketizer extracts AAC access units from RFC 3640 RTP packets. It
+// handles packets carrying several access units and access units fragmented
+// over several packets. A Depacketizer is not safe for concurrent use.
+type Depacketizer struct {
+	cfg Config
+
+	// Reassembly of a fragmented access unit. After a packet loss, fragments
+	// are discarded up to the end of the current access unit.
+	fragment     []byte
+	fragmentSize int
+	discard      bool
+
+	lastSeq uint16
+	started bool
+}
+
+// NewDepacketizer creates a depacketizer for streams described by cfg.
+func NewDepacketizer(cfg Config) (*Depacketizer, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &Depacketizer{cfg: cfg}, nil
+}
+
+// Depacketize returns the complete access units carried by pkt, in decoding
+// order. It returns no access unit for a packet holding the first fragments
+// of an access unit. A partly received access unit is dropped when a packet
+// is lost.
+func (d *Depacketizer) Depacketize(pkt *rtp.Packet) ([][]byte, error) {
+	if d.started && pkt.SequenceNumber != d.lastSeq+1 {
+		d.fragment = d.fragment[:0]
+		d.fragmentSize = 0
+		d.discard = true
+	}
+	d.started = true
+	d.lastSeq = pkt.SequenceNumber
+
+	payload := pkt.Payload
+	if len(payload) < 2 {
+		return nil, ErrInvalidPayload
+	}
+	headersBits := int(payload[0])<<8 | int(payload[1])
+	headersLen := (headersBits + 7) / 8
+	if 2+headersLen > len(payload) {
+		return nil, ErrInvalidPayload
+	}
+	sizes, err := d.auSizes(payload[2:2+headersLen], headersBits)
+	if err != nil {
+		return nil, err
+	}
+	data := payload[2+headersLen:]
+
+	// A single access unit larger than the payload is a fragment
+	if len(sizes) == 1 && (sizes[0] > len(data) || d.fragmentSize > 0 || d.discard && !pkt.Marker) {
+		return d.reassemble(sizes[0], data, pkt.Marker)
+	}
+	d.discard = false
+
+	units := make([][]byte, 0, len(sizes))
+	for _, size := range sizes {
+		if size > len(data) {
+			return nil, ErrInvalidPayload
+		}
+		units = append(units, data[:size:size])
+		data = data[size:]
+	}
+	return units, nil
+}
+
+// auSizes parses the AU headers section and returns the size of each access
+// unit.
+func (d *Depacketizer) auSizes(headers []byte, bits int) ([]int, error) {
+	var sizes []int
+	pos := 0
+	read := func(n int) int {
+		v := 0
+		for range n {
+			v = v<<1 | int(headers[pos/8]>>(7-pos%8)&1)
+			pos++
+		}
+		return v
+	}
+
+	for i := 0; pos < bits; i++ {
+		index := d.cfg.IndexDeltaLength
+		if i == 0 {
+			index = d.cfg.IndexLength
+		}
+		if pos+d.cfg.SizeLength+index > bits {
+			return nil, ErrInvalidPayload
+		}
+		sizes = append(sizes, read(d.cfg.SizeLength))
+		read(index)
+	}
+	if len(sizes) == 0 {
+		return nil, ErrInvalidPayload
+	}
+	return sizes, nil
+}
+
+// reassemble appends a fragment of an access unit of the given size and
+// returns the access unit once complete.
+func (d *Depacketizer) reassemble(size int, data []byte, marker bool) ([][]byte, error) {
+	if d.discard {
+		d.discard = !marker
+		return nil, nil
+	}
+	if d.fragmentSize != 0 && d.fragmentSize != size {
+		// A new access unit started without the previous one completing
+		d.fragment = d.fragment[:0]
+	}
+	d.fragmentSize = size
+	d.fragment = append(d.fragment, data...)
+
+	if len(d.fragment) < size && !marker {
+		return nil, nil
+	}
+
+	defer func() {
+		d.fragment = nil
+		d.fragmentSize = 0
+	}()
+	if len(d.fragment) != size {
+		return nil, ErrInvalidPayload
+	}
+	return [][]byte{d.fragment}, nil
+}
@@ -0,0 +1,163 @@
+// Package faad2rtp decodes AAC audio received over RTP, such as from a
+// pion/webrtc track, with go-faad2.
+//
+// Packets must use the RFC 3640 mpeg4-generic payload format; the stream
+// parameters come from the a=fmtp line of the SDP:
+//
+//	cfg, err := faad2rtp.ParseFmtp(fmtp)
+//	if err != nil {
+//	    return err
+//	}
+//	next := func() (*rtp.Packet, error) {
+//	    pkt, _, err := track.ReadRTP()
+//	    return pkt, err
+//	}
+//	reader, err := faad2rtp.Open(ctx, cfg, next)
+//	if err != nil {
+//	    return err
+//	}
+//	defer reader.Close(ctx)
+//
+// A [Reader] implements [faad2.PCMReader], so it can be used wherever the
+// readers of package faad2 are.
+package faad2rtp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pion/rtp"
+
+	faad2 "github.com/llehouerou/go-faad2"
+)
+
+// Reader decodes the AAC access units of an RTP stream to PCM.
+//
+// Create a Reader using [Open] and release resources with [Reader.Close].
+type Reader struct {
+	mu     sync.Mutex
+	closed bool
+
+	next         func() (*rtp.Packet, error)
+	depacketizer *Depacketizer
+	decoder      *faad2.Decoder
+
+	// Access units depacketized but not decoded yet
+	units [][]byte
+
+	// Decoded samples not returned yet
+	pcm []int16
+
+	framesRead int64
+}
+
+var _ faad2.PCMReader = (*Reader)(nil)
+
+// Open creates a Reader decoding the packets returned by next, which should
+// return an error such as io.EOF when the stream ends. The options configure
+// the decoder, as for [faad2.NewDecoder].
+//
+// Returns [ErrInvalidFmtp] if cfg has an unsupported AU header layout, or the
+// error of [faad2.Decoder.Init] if its AudioSpecificConfig is invalid.
+func Open(ctx context.Context, cfg Config, next func() (*rtp.Packet, error), opts ...faad2.Option) (*Reader, error) {
+	depacketizer, err := NewDepacketizer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := faad2.NewDecoder(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Init(ctx, cfg.AudioSpecificConfig); err != nil {
+		decoder.Close(ctx)
+		return nil, err
+	}
+
+	return &Reader{
+		next:         next,
+		depacketizer: depacketizer,
+		decoder:      decoder,
+	}, nil
+}
+
+// Read reads decoded interleaved PCM samples into pcm and returns the number
+// of samples read. It returns the samples of at most one access unit, reading
+// packets until one decodes to audio.
+//
+// The error returned by the packet source, such as io.EOF, is returned once
+// all samples decoded before it have been read. Returns
+// [faad2.ErrDecoderClosed] after [Reader.Close].
+func (r *Reader) Read(ctx context.Context, pcm []int16) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, faad2.ErrDecoderClosed
+	}
+
+	for len(r.pcm) == 0 {
+		unit, err := r.nextUnit()
+		if err != nil {
+			return 0, err
+		}
+		r.pcm, err = r.decoder.Decode(ctx, unit)
+		if err != nil {
+			return 0, err
+		}
+		r.framesRead++
+	}
+
+	n := copy(pcm, r.pcm)
+	r.pcm = r.pcm[n:]
+	return n, nil
+}
+
+// nextUnit returns the next access unit, reading packets as needed.
+func (r *Reader) nextUnit() ([]byte, error) {
+	for len(r.units) == 0 {
+		pkt, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+		// Malformed packets are dropped like lost ones
+		if units, err := r.depacketizer.Depacketize(pkt); err == nil {
+			r.units = units
+		}
+	}
+
+	unit := r.units[0]
+	r.units = r.units[1:]
+	return unit, nil
+}
+
+// SampleRate returns the output sample rate in Hz.
+func (r *Reader) SampleRate() uint32 {
+	return r.decoder.SampleRate()
+}
+
+// Channels returns the number of output channels.
+func (r *Reader) Channels() uint8 {
+	return r.decoder.Channels()
+}
+
+// FramesRead returns the number of access units decoded so far.
+func (r *Reader) FramesRead() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.framesRead
+}
+
+// Close releases the decoder. It is safe to call Close multiple times.
+//
+// Note: Close does not stop the packet source passed to [Open].
+func (r *Reader) Close(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.decoder.Close(ctx)
+}
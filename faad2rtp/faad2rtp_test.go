@@ -0,0 +1,200 @@
+package faad2rtp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/pion/rtp"
+)
+
+// silentStereoFrame is a raw AAC-LC frame (CPE) that decodes to stereo silence.
+var silentStereoFrame = []byte{0x21, 0x00, 0x49, 0x90, 0x02, 0x19, 0x00, 0x23, 0x80}
+
+const hbrFmtp = "96 streamtype=5;profile-level-id=15;mode=AAC-hbr;config=1210;SizeLength=13;IndexLength=3;IndexDeltaLength=3"
+
+// hbrPayload builds an AAC-hbr payload with one AU header per size and the
+// given data.
+func hbrPayload(sizes []int, data []byte) []byte {
+	bits := 16 * len(sizes)
+	payload := []byte{byte(bits >> 8), byte(bits)}
+	for _, size := range sizes {
+		payload = append(payload, byte(size>>5), byte(size<<3))
+	}
+	return append(payload, data...)
+}
+
+func packet(seq uint16, marker bool, payload []byte) *rtp.Packet {
+	return &rtp.Packet{
+		Header:  rtp.Header{SequenceNumber: seq, Marker: marker},
+		Payload: payload,
+	}
+}
+
+func TestParseFmtp(t *testing.T) {
+	cfg, err := ParseFmtp(hbrFmtp)
+	if err != nil {
+		t.Fatalf("ParseFmtp failed: %v", err)
+	}
+	if !bytes.Equal(cfg.AudioSpecificConfig, []byte{0x12, 0x10}) {
+		t.Errorf("unexpected config %x", cfg.AudioSpecificConfig)
+	}
+	if cfg.SizeLength != 13 || cfg.IndexLength != 3 || cfg.IndexDeltaLength != 3 {
+		t.Errorf("unexpected AU header layout %+v", cfg)
+	}
+
+	for _, fmtp := range []string{
+		"mode=AAC-hbr;sizelength=13;indexlength=3;indexdeltalength=3",
+		"config=zz;sizelength=13",
+		"config=1210",
+		"config=1210;sizelength=32;indexlength=3",
+	} {
+		if _, err := ParseFmtp(fmtp); !errors.Is(err, ErrInvalidFmtp) {
+			t.Errorf("ParseFmtp(%q): expected ErrInvalidFmtp, got %v", fmtp, err)
+		}
+	}
+}
+
+func TestDepacketize(t *testing.T) {
+	cfg, _ := ParseFmtp(hbrFmtp)
+	d, err := NewDepacketizer(cfg)
+	if err != nil {
+		t.Fatalf("NewDepacketizer failed: %v", err)
+	}
+
+	// Two access units in one packet
+	units, err := d.Depacketize(packet(1, true, hbrPayload([]int{3, 2}, []byte{1, 2, 3, 4, 5})))
+	if err != nil {
+		t.Fatalf("Depacketize failed: %v", err)
+	}
+	if len(units) != 2 || !bytes.Equal(units[0], []byte{1, 2, 3}) || !bytes.Equal(units[1], []byte{4, 5}) {
+		t.Errorf("unexpected units %v", units)
+	}
+
+	// One access unit fragmented over two packets
+	units, _ = d.Depacketize(packet(2, false, hbrPayload([]int{4}, []byte{1, 2})))
+	if len(units) != 0 {
+		t.Errorf("expected no unit for a first fragment, got %v", units)
+	}
+	units, err = d.Depacketize(packet(3, true, hbrPayload([]int{4}, []byte{3, 4})))
+	if err != nil {
+		t.Fatalf("Depacketize failed: %v", err)
+	}
+	if len(units) != 1 || !bytes.Equal(units[0], []byte{1, 2, 3, 4}) {
+		t.Errorf("unexpected reassembled units %v", units)
+	}
+
+	// A lost fragment drops the rest of the access unit
+	d.Depacketize(packet(4, false, hbrPayload([]int{6}, []byte{1, 2})))
+	units, _ = d.Depacketize(packet(6, true, hbrPayload([]int{6}, []byte{5, 6})))
+	if len(units) != 0 {
+		t.Errorf("expected partial unit to be dropped, got %v", units)
+	}
+	units, _ = d.Depacketize(packet(7, true, hbrPayload([]int{1}, []byte{9})))
+	if len(units) != 1 || !bytes.Equal(units[0], []byte{9}) {
+		t.Errorf("expected recovery after loss, got %v", units)
+	}
+
+	if _, err := d.Depacketize(packet(8, true, hbrPayload([]int{3, 3}, []byte{1, 2, 3}))); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("expected ErrInvalidPayload for short data, got %v", err)
+	}
+}
+
+func TestReader(t *testing.T) {
+	ctx := context.Background()
+	cfg, _ := ParseFmtp(hbrFmtp)
+
+	n := len(silentStereoFrame)
+	packets := []*rtp.Packet{
+		packet(1, true, hbrPayload([]int{n, n}, append(append([]byte(nil), silentStereoFrame...), silentStereoFrame...))),
+		packet(2, true, []byte{0x00}), // malformed, dropped
+		packet(3, true, hbrPayload([]int{n}, silentStereoFrame)),
+	}
+	next := func() (*rtp.Packet, error) {
+		if len(packets) == 0 {
+			return nil, io.EOF
+		}
+		pkt := packets[0]
+		packets = packets[1:]
+		return pkt, nil
+	}
+
+	reader, err := Open(ctx, cfg, next)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	total := 0
+	pcm := make([]int16, 1000)
+	for {
+		n, err := reader.Read(ctx, pcm)
+		total += n
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+	}
+
+	// The first access unit primes the decoder
+	if total != 2*2048 {
+		t.Errorf("expected %d samples, got %d", 2*2048, total)
+	}
+	if reader.FramesRead() != 3 {
+		t.Errorf("expected 3 frames read, got %d", reader.FramesRead())
+	}
+	if reader.SampleRate() != 44100 || reader.Channels() != 2 {
+		t.Errorf("unexpected format %d Hz, %d ch", reader.SampleRate(), reader.Channels())
+	}
+
+	if err := reader.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := reader.Close(ctx); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+}
+
+func TestReaderFramesReadDuringRead(t *testing.T) {
+	ctx := context.Background()
+	cfg, _ := ParseFmtp(hbrFmtp)
+
+	seq := uint16(0)
+	next := func() (*rtp.Packet, error) {
+		if seq == 20 {
+			return nil, io.EOF
+		}
+		seq++
+		return packet(seq, true, hbrPayload([]int{len(silentStereoFrame)}, silentStereoFrame)), nil
+	}
+
+	reader, err := Open(ctx, cfg, next)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			_ = reader.FramesRead()
+		}
+	}()
+
+	pcm := make([]int16, 1000)
+	for {
+		if _, err := reader.Read(ctx, pcm); err != nil {
+			break
+		}
+	}
+	<-done
+
+	if reader.FramesRead() != 20 {
+		t.Errorf("expected 20 frames read, got %d", reader.FramesRead())
+	}
+}
@@ -0,0 +1,14 @@
+module github.com/llehouerou/go-faad2/faad2rtp
+
+go 1.25.5
+
+require (
+	github.com/llehouerou/go-faad2 v0.1.0
+	github.com/pion/rtp v1.10.5
+)
+
+require (
+	github.com/pion/randutil v0.1.0 // indirect
+	github.com/tetratelabs/wazero v1.11.0 // indirect
+	golang.org/x/sys v0.38.0 // indirect
+)
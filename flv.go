@@ -0,0 +1,431 @@
+package faad2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// FLV tag types
+const (
+	flvTagAudio = 8
+)
+
+// FLV audio constants
+const (
+	flvSoundFormatAAC      = 10
+	flvAACSequenceHeader   = 0
+	flvAACRaw              = 1
+	flvHeaderMinSize       = 9
+	flvTagHeaderSize       = 11
+	flvPreviousTagSizeSize = 4
+)
+
+var (
+	// ErrInvalidFLV is returned when the FLV stream is invalid.
+	ErrInvalidFLV = errors.New("faad2: invalid FLV stream")
+
+	// ErrFLVNoAAC is returned when an FLV stream ends without an AAC
+	// sequence header, for example because it has no audio at all.
+	ErrFLVNoAAC = errors.New("faad2: no AAC audio in FLV stream")
+)
+
+// flvSoundFormats names the FLV SoundFormat values for error reporting.
+var flvSoundFormats = map[uint8]string{
+	0:  "Linear PCM",
+	1:  "ADPCM",
+	2:  "MP3",
+	3:  "Linear PCM LE",
+	4:  "Nellymoser 16kHz",
+	5:  "Nellymoser 8kHz",
+	6:  "Nellymoser",
+	7:  "G.711 A-law",
+	8:  "G.711 mu-law",
+	11: "Speex",
+	14: "MP3 8kHz",
+	15: "Device-specific",
+}
+
+// FLVReader reads and decodes AAC audio from an FLV (Flash Video) stream.
+//
+// FLV is the container used by RTMP, so FLVReader can decode the audio of live
+// RTMP ingest once the RTMP chunk stream has been reassembled into FLV tags.
+// Video and script tags are skipped. Seeking is not supported.
+//
+// Create an FLVReader using [OpenFLV] and release resources with [FLVReader.Close].
+type FLVReader struct {
+	pcmStream
+
+	reader io.Reader
+
+	// Tag header buffer for reading
+	tagHeader [flvTagHeaderSize]byte
+
+	// AudioSpecificConfig of the current frames, and that of a sequence
+	// header announcing another format for the frames after it
+	config        []byte
+	pendingConfig []byte
+}
+
+// flvTag is an FLV tag header.
+type flvTag struct {
+	tagType   uint8
+	dataSize  uint32
+	timestamp uint32
+}
+
+// OpenFLV opens an FLV stream for audio decoding.
+//
+// The reader should provide FLV data starting with the "FLV" signature. Tags
+// are read until the AAC sequence header (the AudioSpecificConfig) is found,
+// which is used to initialize the decoder. Raw AAC tags preceding it are
+// discarded. A later sequence header with another config replaces the
+// decoder and calls the [WithFormatChange] callback.
+//
+// Returns [ErrInvalidFLV] if the header is malformed, [ErrTruncated] if it is
+// cut short, [ErrFLVNoAAC] if the stream ends before an AAC sequence header,
+// or an [*UnsupportedCodecError]
+// (matching [ErrUnsupportedCodec]) if the audio uses another codec.
+func OpenFLV(ctx context.Context, r io.Reader, opts ...Option) (*FLVReader, error) {
+	o := newOptions(opts)
+
+	var gain float64
+	if o.normalizePeak > 0 {
+		open := func(ctx context.Context, r io.Reader, opts ...Option) (closingPCMReader, error) {
+			fr, err := OpenFLV(ctx, r, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return fr, nil
+		}
+		g, err := peakGain(ctx, r, o.normalizePeak, open, opts)
+		if err != nil {
+			return nil, err
+		}
+		gain = g
+	}
+
+	deadliner, _ := r.(readDeadliner)
+	fr := &FLVReader{
+		pcmStream: pcmStream{opts: o, gain: gain, deadliner: deadliner},
+		reader:    o.teeReader(r),
+	}
+	fr.nextFrame = fr.readFrame
+
+	if err := fr.readFileHeader(); err != nil {
+		return nil, err
+	}
+
+	// Scan tags until the AAC sequence header
+	var config []byte
+	for config == nil {
+		packetType, data, timestamp, err := fr.readAudioTag()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, ErrFLVNoAAC
+			}
+			return nil, err
+		}
+		fr.timestamp = timestamp
+		if packetType == flvAACSequenceHeader {
+			config = data
+		}
+	}
+
+	decoder, err := NewDecoder(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = decoder.Init(ctx, config)
+	if err != nil {
+		decoder.Close(ctx)
+		return nil, err
+	}
+
+	fr.decoder = decoder
+	fr.config = config
+	fr.asc, _ = parseAudioSpecificConfig(config)
+	if err := fr.checkChannel(); err != nil {
+		decoder.Close(ctx)
+		return nil, err
+	}
+
+	return fr, nil
+}
+
+// Read reads decoded PCM samples into the provided buffer.
+//
+// Returns the number of samples read into pcm. For stereo audio, each sample
+// pair (L, R) counts as 2 samples. Errors follow the same conventions as
+// [ADTSReader.Read]: (n, nil) first, then (0, err), with io.EOF sticky.
+//
+// The buffer can be any size; the reader handles internal buffering.
+func (fr *FLVReader) Read(ctx context.Context, pcm []int16) (int, error) {
+	return fr.read(ctx, pcm)
+}
+
+// ReadTimed reads like [FLVReader.Read] and also returns the stream time of
+// the first sample read. See [ADTSReader.ReadTimed].
+func (fr *FLVReader) ReadTimed(ctx context.Context, pcm []int16) (n int, start time.Duration, err error) {
+	return fr.readTimed(ctx, pcm)
+}
+
+// ReadPlanar reads decoded samples into one slice per channel. It behaves
+// like [ADTSReader.ReadPlanar].
+func (fr *FLVReader) ReadPlanar(ctx context.Context, out [][]int16) (int, error) {
+	return fr.readPlanar(ctx, out)
+}
+
+// ReadFrame decodes the next AAC frame and returns its interleaved PCM. It
+// behaves like [ADTSReader.ReadFrame].
+func (fr *FLVReader) ReadFrame(ctx context.Context) ([]int16, error) {
+	return fr.readPCMFrame(ctx)
+}
+
+// ReadFrames reads exactly frames samples per channel and returns them
+// interleaved. It behaves like [ADTSReader.ReadFrames].
+func (fr *FLVReader) ReadFrames(ctx context.Context, frames int) ([]int16, error) {
+	return fr.readFrames(ctx, frames)
+}
+
+// SampleRate returns the audio sample rate in Hz (e.g., 44100, 48000).
+//
+// Returns 0 after the reader has been closed.
+func (fr *FLVReader) SampleRate() uint32 {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.sampleRateLocked()
+}
+
+// sampleRateLocked implements SampleRate. The caller must hold fr.mu.
+func (fr *FLVReader) sampleRateLocked() uint32 {
+	if fr.decoder == nil {
+		return 0
+	}
+	return fr.decoder.SampleRate()
+}
+
+// Channels returns the number of output audio channels.
+//
+// Returns 0 after the reader has been closed, and 1 when a single channel is
+// selected with [WithChannel].
+func (fr *FLVReader) Channels() uint8 {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.channelsLocked()
+}
+
+// channelsLocked implements Channels. The caller must hold fr.mu.
+func (fr *FLVReader) channelsLocked() uint8 {
+	if fr.decoder == nil {
+		return 0
+	}
+	if fr.opts.extractChannel {
+		return 1
+	}
+	return fr.decoder.Channels()
+}
+
+// PCMFormat returns the format of the samples returned by Read. See
+// [ADTSReader.PCMFormat].
+func (fr *FLVReader) PCMFormat() PCMFormat {
+	return readerPCMFormat
+}
+
+// FramesRead returns the number of AAC frames decoded so far.
+func (fr *FLVReader) FramesRead() int64 {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.framesRead
+}
+
+// Timestamp returns the FLV timestamp of the last AAC frame read, in milliseconds.
+func (fr *FLVReader) Timestamp() uint32 {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.timestamp
+}
+
+// Info returns a snapshot of the stream properties and read progress.
+func (fr *FLVReader) Info() Info {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.info("FLV", fr.sampleRateLocked(), fr.channelsLocked())
+}
+
+// String summarizes the reader state for logging, in the same format as
+// [ADTSReader.String].
+func (fr *FLVReader) String() string {
+	return fr.describe("FLVReader", fr.Info())
+}
+
+// PrimingSamples returns the number of samples per channel that the decoder
+// withheld at the start of the stream. See [ADTSReader.PrimingSamples].
+func (fr *FLVReader) PrimingSamples() int {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.primingSamples()
+}
+
+// FramesSkipped returns the number of corrupt frames replaced with silence
+// or, with [WithRepeatConcealment], with the previous frame.
+//
+// It is always 0 unless the reader was opened with [WithSkipCorruptFrames].
+func (fr *FLVReader) FramesSkipped() int64 {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.framesSkipped
+}
+
+// Concealment returns the number of corrupt frames concealed with each
+// method. See [ADTSReader.Concealment].
+func (fr *FLVReader) Concealment() Concealment {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.concealment
+}
+
+// Close releases all resources associated with the reader.
+//
+// After Close is called, the reader cannot be reused and Read returns
+// [ErrDecoderClosed]. It is safe to call Close multiple times, and from
+// another goroutine while a Read is in progress; Close then waits for that
+// Read to return.
+//
+// Note: Close does not close the underlying io.Reader passed to [OpenFLV].
+func (fr *FLVReader) Close(ctx context.Context) error {
+	return fr.close(ctx)
+}
+
+// readFileHeader reads the FLV file header and the first PreviousTagSize field.
+func (fr *FLVReader) readFileHeader() error {
+	var header [flvHeaderMinSize]byte
+	if _, err := io.ReadFull(fr.reader, header[:]); err != nil {
+		return ErrInvalidFLV
+	}
+	if header[0] != 'F' || header[1] != 'L' || header[2] != 'V' {
+		return ErrInvalidFLV
+	}
+
+	dataOffset := uint32(header[5])<<24 | uint32(header[6])<<16 | uint32(header[7])<<8 | uint32(header[8])
+	if dataOffset < flvHeaderMinSize {
+		return &RangeError{Field: "FLV data offset", Value: int64(dataOffset), Err: ErrInvalidFLV}
+	}
+
+	// Skip any header extension and PreviousTagSize0
+	skip := int64(dataOffset-flvHeaderMinSize) + flvPreviousTagSizeSize
+	if _, err := io.CopyN(io.Discard, fr.reader, skip); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrTruncated
+		}
+		return fmt.Errorf("%w: %w", ErrInvalidFLV, err)
+	}
+	return nil
+}
+
+// readTagHeader reads the next FLV tag header.
+func (fr *FLVReader) readTagHeader() (flvTag, error) {
+	if _, err := io.ReadFull(fr.reader, fr.tagHeader[:]); err != nil {
+		return flvTag{}, err
+	}
+
+	h := fr.tagHeader
+	return flvTag{
+		tagType:   h[0] & 0x1F,
+		dataSize:  uint32(h[1])<<16 | uint32(h[2])<<8 | uint32(h[3]),
+		timestamp: uint32(h[7])<<24 | uint32(h[4])<<16 | uint32(h[5])<<8 | uint32(h[6]),
+	}, nil
+}
+
+// readAudioTag reads tags until the next AAC audio tag and returns its
+// AACPacketType, payload and timestamp. Non-audio tags are skipped.
+func (fr *FLVReader) readAudioTag() (uint8, []byte, uint32, error) {
+	for {
+		tag, err := fr.readTagHeader()
+		if err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				err = ErrTruncated
+			}
+			return 0, nil, 0, err
+		}
+
+		if tag.tagType != flvTagAudio || tag.dataSize < 2 {
+			// Skip tag data and PreviousTagSize, which a cut live stream may
+			// lack as for audio tags
+			if _, err := io.CopyN(io.Discard, fr.reader, int64(tag.dataSize)); err != nil {
+				if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+					err = ErrTruncated
+				}
+				return 0, nil, 0, err
+			}
+			if _, err := io.CopyN(io.Discard, fr.reader, flvPreviousTagSizeSize); err != nil && !errors.Is(err, io.EOF) {
+				return 0, nil, 0, err
+			}
+			continue
+		}
+
+		if limit := fr.opts.limits.MaxFrameSize; limit > 0 && int(tag.dataSize) > limit {
+			return 0, nil, 0, ErrLimitExceeded
+		}
+
+		data := make([]byte, tag.dataSize)
+		if _, err := io.ReadFull(fr.reader, data); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				err = ErrTruncated
+			}
+			return 0, nil, 0, err
+		}
+
+		// A live stream may be cut right after the tag data; the next header
+		// read reports the end of stream.
+		var prevTagSize [flvPreviousTagSizeSize]byte
+		if _, err := io.ReadFull(fr.reader, prevTagSize[:]); err != nil &&
+			!errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil, 0, err
+		}
+
+		if format := data[0] >> 4; format != flvSoundFormatAAC {
+			name, ok := flvSoundFormats[format]
+			if !ok {
+				name = "FLV sound format " + strconv.Itoa(int(format))
+			}
+			return 0, nil, 0, &UnsupportedCodecError{Codec: name}
+		}
+
+		return data[1], data[2:], tag.timestamp, nil
+	}
+}
+
+// readFrame returns the next raw AAC frame. A sequence header with another
+// config than the current one, as sent when an RTMP publisher changes its
+// encoder settings, is returned as the config of the frame after it so that
+// the decoder is replaced before decoding that frame.
+func (fr *FLVReader) readFrame() (containerFrame, error) {
+	for {
+		packetType, data, timestamp, err := fr.readAudioTag()
+		if err != nil {
+			return containerFrame{}, err
+		}
+		switch {
+		case packetType == flvAACSequenceHeader:
+			if bytes.Equal(data, fr.config) {
+				fr.pendingConfig = nil
+			} else {
+				fr.pendingConfig = data
+			}
+		case packetType == flvAACRaw && len(data) > 0:
+			frame := containerFrame{data: data, offset: -1, timestamp: timestamp}
+			if fr.pendingConfig != nil {
+				frame.config = fr.pendingConfig
+				fr.config = fr.pendingConfig
+				fr.pendingConfig = nil
+			}
+			return frame, nil
+		}
+	}
+}
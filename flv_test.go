@@ -0,0 +1,290 @@
+package faad2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+// flvTagBytes builds an FLV tag followed by its PreviousTagSize field.
+func flvTagBytes(tagType byte, timestamp uint32, data []byte) []byte {
+	size := len(data)
+	tag := []byte{
+		tagType,
+		byte(size >> 16), byte(size >> 8), byte(size),
+		byte(timestamp >> 16), byte(timestamp >> 8), byte(timestamp), byte(timestamp >> 24),
+		0, 0, 0,
+	}
+	tag = append(tag, data...)
+	total := size + flvTagHeaderSize
+	return append(tag, byte(total>>24), byte(total>>16), byte(total>>8), byte(total))
+}
+
+// buildTestFLVStream builds an FLV stream with an AAC-LC 44100Hz stereo
+// sequence header, a video tag, and the given number of silent AAC frames.
+func buildTestFLVStream(frames int) []byte {
+	return buildTestFLVStreamWith([]byte{0x12, 0x10}, frames)
+}
+
+// buildTestFLVStreamWith builds an FLV stream like buildTestFLVStream with the
+// given AudioSpecificConfig in the sequence header.
+func buildTestFLVStreamWith(config []byte, frames int) []byte {
+	stream := []byte{'F', 'L', 'V', 0x01, 0x05, 0x00, 0x00, 0x00, 0x09, 0x00, 0x00, 0x00, 0x00}
+
+	// AAC, 44kHz, 16-bit, stereo
+	const soundFlags = 0xAF
+
+	stream = append(stream, flvTagBytes(9, 0, []byte{0x17, 0x00, 0x00, 0x00, 0x00})...)
+	stream = append(stream, flvTagBytes(flvTagAudio, 0, append([]byte{soundFlags, flvAACSequenceHeader}, config...))...)
+	for i := range frames {
+		data := append([]byte{soundFlags, flvAACRaw}, silentStereoFrame...)
+		stream = append(stream, flvTagBytes(flvTagAudio, uint32(i*23), data)...)
+	}
+	return stream
+}
+
+func TestOpenFLV(t *testing.T) {
+	ctx := context.Background()
+
+	reader, err := OpenFLV(ctx, bytes.NewReader(buildTestFLVStream(10)))
+	if err != nil {
+		t.Fatalf("OpenFLV failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	if reader.SampleRate() != 44100 {
+		t.Errorf("expected sample rate 44100, got %d", reader.SampleRate())
+	}
+	if reader.Channels() != 2 {
+		t.Errorf("expected 2 channels, got %d", reader.Channels())
+	}
+
+	pcm := make([]int16, 1000)
+	total := 0
+	for {
+		n, err := reader.Read(ctx, pcm)
+		total += n
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+	}
+
+	if reader.FramesRead() != 10 {
+		t.Errorf("expected 10 frames read, got %d", reader.FramesRead())
+	}
+	// The first frame primes the decoder and produces no output
+	if total != 9*2048 {
+		t.Errorf("expected %d samples, got %d", 9*2048, total)
+	}
+	if reader.Timestamp() != 9*23 {
+		t.Errorf("expected last timestamp %d, got %d", 9*23, reader.Timestamp())
+	}
+}
+
+func TestFLVSequenceHeaderChange(t *testing.T) {
+	ctx := context.Background()
+
+	// A repeated sequence header is ignored, a new one switches to 48kHz
+	stream := buildTestFLVStream(5)
+	for _, config := range [][]byte{{0x12, 0x10}, {0x11, 0x90}} {
+		data := append([]byte{0xAF, flvAACSequenceHeader}, config...)
+		stream = append(stream, flvTagBytes(flvTagAudio, 5*23, data)...)
+	}
+	for i := range 5 {
+		data := append([]byte{0xAF, flvAACRaw}, silentStereoFrame...)
+		stream = append(stream, flvTagBytes(flvTagAudio, uint32((5+i)*23), data)...)
+	}
+
+	var changes int
+	var changedRate uint32
+	onChange := func(sampleRate uint32, _ uint8) {
+		changes++
+		changedRate = sampleRate
+	}
+	reader, err := OpenFLV(ctx, bytes.NewReader(stream), WithFormatChange(onChange))
+	if err != nil {
+		t.Fatalf("OpenFLV failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	readAllSamples(t, reader.Read, 1000)
+
+	if changes != 1 || changedRate != 48000 {
+		t.Errorf("expected one change to 48000 Hz, got %d changes to %d Hz", changes, changedRate)
+	}
+	if reader.SampleRate() != 48000 {
+		t.Errorf("expected sample rate 48000, got %d", reader.SampleRate())
+	}
+	if reader.FramesRead() != 10 {
+		t.Errorf("expected 10 frames read, got %d", reader.FramesRead())
+	}
+}
+
+func TestOpenFLVInvalid(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := OpenFLV(ctx, bytes.NewReader([]byte("not an flv file")))
+	if !errors.Is(err, ErrInvalidFLV) {
+		t.Errorf("expected ErrInvalidFLV, got %v", err)
+	}
+
+	// Data offset inside the signature
+	header := buildTestFLVStream(0)[:13]
+	header[8] = 4
+	_, err = OpenFLV(ctx, bytes.NewReader(header))
+	var rangeErr *RangeError
+	if !errors.As(err, &rangeErr) || rangeErr.Value != 4 || !errors.Is(err, ErrInvalidFLV) {
+		t.Errorf("expected RangeError for data offset 4, got %v", err)
+	}
+
+	// Header extension cut short
+	extended := buildTestFLVStream(0)[:13]
+	extended[8] = 20
+	_, err = OpenFLV(ctx, bytes.NewReader(extended))
+	if !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated for a cut header extension, got %v", err)
+	}
+
+	// Read error while skipping the header extension
+	errRead := errors.New("read failed")
+	failing := io.MultiReader(bytes.NewReader(buildTestFLVStream(0)[:9]), iotest.ErrReader(errRead))
+	_, err = OpenFLV(ctx, failing)
+	if !errors.Is(err, ErrInvalidFLV) || !errors.Is(err, errRead) {
+		t.Errorf("expected wrapped read error, got %v", err)
+	}
+
+	// Header only, no audio
+	_, err = OpenFLV(ctx, bytes.NewReader(buildTestFLVStream(0)[:13]))
+	if !errors.Is(err, ErrFLVNoAAC) {
+		t.Errorf("expected ErrFLVNoAAC, got %v", err)
+	}
+
+	// MP3 audio tag
+	mp3 := buildTestFLVStream(0)[:13]
+	mp3 = append(mp3, flvTagBytes(flvTagAudio, 0, []byte{0x2F, 0xFF, 0xFB})...)
+	_, err = OpenFLV(ctx, bytes.NewReader(mp3))
+	if !errors.Is(err, ErrUnsupportedCodec) {
+		t.Errorf("expected ErrUnsupportedCodec for MP3 audio, got %v", err)
+	}
+	var codecErr *UnsupportedCodecError
+	if !errors.As(err, &codecErr) || codecErr.Codec != "MP3" {
+		t.Errorf("expected UnsupportedCodecError with codec MP3, got %v", err)
+	}
+}
+
+func TestFLVTruncatedSkippedTag(t *testing.T) {
+	ctx := context.Background()
+
+	// Cut inside the data of a video tag after the audio
+	stream := buildTestFLVStream(3)
+	stream = append(stream, flvTagBytes(9, 100, make([]byte, 32))...)
+	stream = stream[:len(stream)-20]
+
+	for _, allow := range []bool{false, true} {
+		var opts []Option
+		if allow {
+			opts = append(opts, WithAllowTruncated())
+		}
+		reader, err := OpenFLV(ctx, bytes.NewReader(stream), opts...)
+		if err != nil {
+			t.Fatalf("OpenFLV failed: %v", err)
+		}
+
+		pcm := make([]int16, 8192)
+		for {
+			_, err = reader.Read(ctx, pcm)
+			if err != nil {
+				break
+			}
+		}
+		reader.Close(ctx)
+
+		want := ErrTruncated
+		if allow {
+			want = io.EOF
+		}
+		if !errors.Is(err, want) {
+			t.Errorf("allowTruncated=%v: expected %v, got %v", allow, want, err)
+		}
+	}
+}
+
+func TestFLVFormatDuringClose(t *testing.T) {
+	ctx := context.Background()
+
+	reader, err := OpenFLV(ctx, bytes.NewReader(buildTestFLVStream(20)))
+	if err != nil {
+		t.Fatalf("OpenFLV failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			_ = reader.SampleRate()
+			_ = reader.Channels()
+		}
+	}()
+
+	_, _ = reader.Read(ctx, make([]int16, 4096))
+	if err := reader.Close(ctx); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	<-done
+
+	if reader.SampleRate() != 0 || reader.Channels() != 0 {
+		t.Errorf("expected 0 Hz, 0 channels after Close, got %d Hz, %d channels", reader.SampleRate(), reader.Channels())
+	}
+}
+
+func TestFLVCloseIdempotent(t *testing.T) {
+	ctx := context.Background()
+
+	reader, err := OpenFLV(ctx, bytes.NewReader(buildTestFLVStream(2)))
+	if err != nil {
+		t.Fatalf("OpenFLV failed: %v", err)
+	}
+
+	if err := reader.Close(ctx); err != nil {
+		t.Errorf("First Close failed: %v", err)
+	}
+	if err := reader.Close(ctx); err != nil {
+		t.Errorf("Second Close failed: %v", err)
+	}
+
+	_, err = reader.Read(ctx, make([]int16, 16))
+	if err == nil {
+		t.Error("expected error when reading after Close")
+	}
+}
+
+func TestFLVTruncated(t *testing.T) {
+	ctx := context.Background()
+
+	stream := buildTestFLVStream(3)
+	// Cut inside the last tag's data
+	stream = stream[:len(stream)-8]
+
+	reader, err := OpenFLV(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenFLV failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	pcm := make([]int16, 8192)
+	for {
+		_, err = reader.Read(ctx, pcm)
+		if err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated, got %v", err)
+	}
+}
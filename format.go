@@ -0,0 +1,160 @@
+package faad2
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strconv"
+)
+
+// ErrInvalidPCMFormat is returned when converting to a [PCMFormat] that is not
+// supported.
+var ErrInvalidPCMFormat = errors.New("faad2: unsupported PCM format")
+
+// SampleEncoding is the numeric encoding of PCM samples.
+type SampleEncoding uint8
+
+const (
+	// EncodingSigned is two's complement integers, silence at 0.
+	EncodingSigned SampleEncoding = iota
+
+	// EncodingUnsigned is offset binary integers, silence at half range, as
+	// used by 8-bit WAV.
+	EncodingUnsigned
+
+	// EncodingFloat is IEEE 754 floating point in the range [-1, 1].
+	EncodingFloat
+)
+
+// PCMFormat describes a PCM sample layout, for negotiating the format of an
+// audio output such as ALSA, CoreAudio or WASAPI.
+//
+// Readers return 16-bit signed interleaved samples in host byte order, as
+// reported by their PCMFormat method. [PCMFormat.Append] converts them to any
+// other supported format.
+type PCMFormat struct {
+	// BitDepth is the size of a sample in bits: 8, 16, 24 (packed in 3
+	// bytes) or 32 for integers, 32 or 64 for floats.
+	BitDepth int
+
+	Encoding  SampleEncoding
+	BigEndian bool
+
+	// Planar stores each channel contiguously rather than interleaved.
+	Planar bool
+}
+
+// nativeBigEndian reports whether the host stores integers big-endian.
+var nativeBigEndian = binary.NativeEndian.Uint16([]byte{0, 1}) == 1
+
+// readerPCMFormat is the format of the samples returned by Read.
+var readerPCMFormat = PCMFormat{BitDepth: 16, Encoding: EncodingSigned, BigEndian: nativeBigEndian}
+
+// BytesPerSample returns the size of one sample of one channel.
+func (f PCMFormat) BytesPerSample() int {
+	return f.BitDepth / 8
+}
+
+// Validate returns [ErrInvalidPCMFormat] if f is not a supported format.
+func (f PCMFormat) Validate() error {
+	switch f.Encoding {
+	case EncodingSigned, EncodingUnsigned:
+		if f.BitDepth == 8 || f.BitDepth == 16 || f.BitDepth == 24 || f.BitDepth == 32 {
+			return nil
+		}
+	case EncodingFloat:
+		if f.BitDepth == 32 || f.BitDepth == 64 {
+			return nil
+		}
+	}
+	return ErrInvalidPCMFormat
+}
+
+// String returns the format name in the style of FFmpeg, such as "s16le",
+// "u8" or "f32be", followed by " planar" for planar layouts.
+func (f PCMFormat) String() string {
+	var name string
+	switch f.Encoding {
+	case EncodingSigned:
+		name = "s"
+	case EncodingUnsigned:
+		name = "u"
+	case EncodingFloat:
+		name = "f"
+	default:
+		name = "?"
+	}
+	name += strconv.Itoa(f.BitDepth)
+	if f.BitDepth > 8 {
+		if f.BigEndian {
+			name += "be"
+		} else {
+			name += "le"
+		}
+	}
+	if f.Planar {
+		name += " planar"
+	}
+	return name
+}
+
+// Append converts interleaved 16-bit samples with the given number of
+// channels, as returned by Read, to format f and appends them to dst.
+//
+// Returns [ErrInvalidPCMFormat] if f is not supported, or [ErrInvalidChannel]
+// if len(pcm) is not a multiple of channels.
+func (f PCMFormat) Append(dst []byte, pcm []int16, channels int) ([]byte, error) {
+	if err := f.Validate(); err != nil {
+		return dst, err
+	}
+	if channels <= 0 || len(pcm)%channels != 0 {
+		return dst, ErrInvalidChannel
+	}
+
+	var order binary.AppendByteOrder = binary.LittleEndian
+	if f.BigEndian {
+		order = binary.BigEndian
+	}
+
+	frames := len(pcm) / channels
+	for i := range len(pcm) {
+		// Planar output walks the samples channel by channel
+		j := i
+		if f.Planar {
+			j = (i%frames)*channels + i/frames
+		}
+		dst = f.appendSample(dst, order, pcm[j])
+	}
+	return dst, nil
+}
+
+// appendSample appends one sample in format f.
+func (f PCMFormat) appendSample(dst []byte, order binary.AppendByteOrder, s int16) []byte {
+	if f.Encoding == EncodingFloat {
+		v := float64(s) / 32768
+		if f.BitDepth == 32 {
+			return order.AppendUint32(dst, math.Float32bits(float32(v)))
+		}
+		return order.AppendUint64(dst, math.Float64bits(v))
+	}
+
+	// Scale to the target depth, keeping the sample left-aligned
+	v := int32(s) << 16
+	if f.Encoding == EncodingUnsigned {
+		v ^= math.MinInt32
+	}
+	u := uint32(v) //nolint:gosec // bit pattern
+	switch f.BitDepth {
+	case 8:
+		return append(dst, byte(u>>24))
+	case 16:
+		return order.AppendUint16(dst, uint16(u>>16))
+	case 24:
+		if f.BigEndian {
+			return append(dst, byte(u>>24), byte(u>>16), byte(u>>8))
+		}
+		return append(dst, byte(u>>8), byte(u>>16), byte(u>>24))
+	default:
+		return order.AppendUint32(dst, u)
+	}
+}
@@ -0,0 +1,99 @@
+package faad2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPCMFormatAppend(t *testing.T) {
+	pcm := []int16{0x1234, -2, -32768, 32767}
+
+	tests := []struct {
+		format PCMFormat
+		want   []byte
+	}{
+		{PCMFormat{BitDepth: 16}, []byte{0x34, 0x12, 0xFE, 0xFF, 0x00, 0x80, 0xFF, 0x7F}},
+		{PCMFormat{BitDepth: 16, BigEndian: true}, []byte{0x12, 0x34, 0xFF, 0xFE, 0x80, 0x00, 0x7F, 0xFF}},
+		{PCMFormat{BitDepth: 16, Planar: true}, []byte{0x34, 0x12, 0x00, 0x80, 0xFE, 0xFF, 0xFF, 0x7F}},
+		{PCMFormat{BitDepth: 8, Encoding: EncodingUnsigned}, []byte{0x92, 0x7F, 0x00, 0xFF}},
+		{PCMFormat{BitDepth: 8}, []byte{0x12, 0xFF, 0x80, 0x7F}},
+		{PCMFormat{BitDepth: 24}, []byte{
+			0x00, 0x34, 0x12, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x80, 0x00, 0xFF, 0x7F,
+		}},
+		{PCMFormat{BitDepth: 32, BigEndian: true}, []byte{
+			0x12, 0x34, 0x00, 0x00, 0xFF, 0xFE, 0x00, 0x00, 0x80, 0x00, 0x00, 0x00, 0x7F, 0xFF, 0x00, 0x00,
+		}},
+		{PCMFormat{BitDepth: 32, Encoding: EncodingFloat}, []byte{
+			0x00, 0xA0, 0x11, 0x3E, 0x00, 0x00, 0x80, 0xB8, 0x00, 0x00, 0x80, 0xBF, 0x00, 0xFE, 0x7F, 0x3F,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			got, err := tt.format.Append(nil, pcm, 2)
+			if err != nil {
+				t.Fatalf("Append failed: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("expected % x, got % x", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPCMFormatInvalid(t *testing.T) {
+	for _, f := range []PCMFormat{
+		{BitDepth: 12},
+		{BitDepth: 16, Encoding: EncodingFloat},
+		{BitDepth: 64},
+		{BitDepth: 16, Encoding: 7},
+	} {
+		if _, err := f.Append(nil, []int16{0}, 1); !errors.Is(err, ErrInvalidPCMFormat) {
+			t.Errorf("%+v: expected ErrInvalidPCMFormat, got %v", f, err)
+		}
+	}
+
+	if _, err := (PCMFormat{BitDepth: 16}).Append(nil, []int16{1, 2, 3}, 2); !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("expected ErrInvalidChannel for a partial frame, got %v", err)
+	}
+}
+
+func TestPCMFormatString(t *testing.T) {
+	tests := map[string]PCMFormat{
+		"s16le":        {BitDepth: 16},
+		"u8":           {BitDepth: 8, Encoding: EncodingUnsigned},
+		"f32be":        {BitDepth: 32, Encoding: EncodingFloat, BigEndian: true},
+		"s24le planar": {BitDepth: 24, Planar: true},
+	}
+	for want, f := range tests {
+		if got := f.String(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestReaderPCMFormat(t *testing.T) {
+	ctx := context.Background()
+	reader, err := OpenADTSBytes(ctx, buildTestADTSStream(3))
+	if err != nil {
+		t.Fatalf("OpenADTSBytes failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	f := reader.PCMFormat()
+	if f.BitDepth != 16 || f.Encoding != EncodingSigned || f.Planar {
+		t.Errorf("unexpected reader format %v", f)
+	}
+
+	// Converting to the reader format is a plain byte copy of the samples
+	pcm := []int16{1, -1}
+	got, _ := f.Append(nil, pcm, 2)
+	want := []byte{0x01, 0x00, 0xFF, 0xFF}
+	if f.BigEndian {
+		want = []byte{0x00, 0x01, 0xFF, 0xFF}
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("expected % x, got % x", want, got)
+	}
+}
@@ -0,0 +1,63 @@
+package faad2
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+// PCMReader is implemented by the readers of this package, such as
+// [ADTSReader] and [FLVReader].
+type PCMReader interface {
+	Read(ctx context.Context, pcm []int16) (int, error)
+	SampleRate() uint32
+	Channels() uint8
+}
+
+// HashPCM decodes r to the end and returns a hex-encoded SHA-256 digest of its
+// output, for golden-output regression tests that detect decoder drift.
+//
+// The digest covers every sample as 16-bit little-endian, followed by the
+// final sample rate (32-bit little-endian) and channel count (one byte), so
+// it is independent of the host byte order and of read buffer sizes.
+func HashPCM(ctx context.Context, r PCMReader) (string, error) {
+	h := sha256.New()
+	pcm := make([]int16, 8192)
+	buf := make([]byte, 2*len(pcm))
+
+	for {
+		n, err := r.Read(ctx, pcm)
+		for i, sample := range pcm[:n] {
+			binary.LittleEndian.PutUint16(buf[2*i:], uint16(sample)) //nolint:gosec // bit pattern
+		}
+		h.Write(buf[:2*n])
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var format [5]byte
+	binary.LittleEndian.PutUint32(format[:4], r.SampleRate())
+	format[4] = r.Channels()
+	h.Write(format[:])
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashADTS decodes an ADTS stream and returns its [HashPCM] digest.
+func HashADTS(ctx context.Context, r io.Reader, opts ...Option) (string, error) {
+	ar, err := OpenADTS(ctx, r, opts...)
+	if err != nil {
+		return "", err
+	}
+	defer ar.Close(ctx)
+
+	return HashPCM(ctx, ar)
+}
@@ -0,0 +1,70 @@
+package faad2
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// bufferSizeReader reads through its underlying reader in fixed small chunks.
+type bufferSizeReader struct {
+	PCMReader
+	size int
+}
+
+func (r bufferSizeReader) Read(ctx context.Context, pcm []int16) (int, error) {
+	return r.PCMReader.Read(ctx, pcm[:min(len(pcm), r.size)])
+}
+
+func TestHashADTS(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(20)
+
+	first, err := HashADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("HashADTS failed: %v", err)
+	}
+	if len(first) != 64 {
+		t.Errorf("expected 64 hex characters, got %q", first)
+	}
+
+	second, err := HashADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("HashADTS failed: %v", err)
+	}
+	if first != second {
+		t.Errorf("hash is not deterministic: %s != %s", first, second)
+	}
+
+	shorter, err := HashADTS(ctx, bytes.NewReader(buildTestADTSStream(19)))
+	if err != nil {
+		t.Fatalf("HashADTS failed: %v", err)
+	}
+	if shorter == first {
+		t.Error("expected different hash for different output")
+	}
+}
+
+func TestHashPCMIndependentOfReadSize(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(10)
+
+	want, err := HashADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("HashADTS failed: %v", err)
+	}
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	got, err := HashPCM(ctx, bufferSizeReader{PCMReader: reader, size: 333})
+	if err != nil {
+		t.Fatalf("HashPCM failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
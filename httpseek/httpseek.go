@@ -0,0 +1,174 @@
+// Package httpseek provides an [io.ReadSeeker] over HTTP Range requests.
+//
+// It allows decoding remote audio files (S3, CDN) without downloading them
+// entirely:
+//
+//	r, err := httpseek.Open(ctx, "https://example.com/audio.aac")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	reader, err := faad2.OpenADTS(ctx, r)
+//
+// Data is fetched in fixed-size blocks through a [blockcache.Reader], so
+// sequential reads and short backward seeks do not issue a request per call.
+package httpseek
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/llehouerou/go-faad2/blockcache"
+)
+
+const (
+	// DefaultBlockSize is the default number of bytes fetched per request.
+	DefaultBlockSize = 256 * 1024
+
+	// DefaultCacheBlocks is the default number of blocks kept in memory.
+	DefaultCacheBlocks = 8
+)
+
+// ErrRangeNotSupported is returned when the server ignores Range requests.
+var ErrRangeNotSupported = errors.New("httpseek: server does not support range requests")
+
+// Reader reads a remote HTTP resource using Range requests.
+//
+// A Reader is not safe for concurrent use.
+type Reader struct {
+	*blockcache.Reader
+
+	ctx         context.Context
+	client      *http.Client
+	url         string
+	blockSize   int
+	cacheBlocks int
+}
+
+// Option configures a [Reader].
+type Option func(*Reader)
+
+// WithClient sets the HTTP client used for requests. Defaults to [http.DefaultClient].
+func WithClient(client *http.Client) Option {
+	return func(r *Reader) {
+		r.client = client
+	}
+}
+
+// WithBlockSize sets the number of bytes fetched per request.
+func WithBlockSize(size int) Option {
+	return func(r *Reader) {
+		if size > 0 {
+			r.blockSize = size
+		}
+	}
+}
+
+// WithCacheBlocks sets the number of blocks kept in memory.
+func WithCacheBlocks(n int) Option {
+	return func(r *Reader) {
+		if n > 0 {
+			r.cacheBlocks = n
+		}
+	}
+}
+
+// Open prepares a Reader for the resource at url.
+//
+// Open issues a single-byte request to determine the resource size and verify
+// that the server honors Range requests. The context is used for all
+// subsequent requests made by the Reader.
+//
+// Returns [ErrRangeNotSupported] if the server responds without partial content.
+func Open(ctx context.Context, url string, opts ...Option) (*Reader, error) {
+	r := &Reader{
+		ctx:         ctx,
+		client:      http.DefaultClient,
+		url:         url,
+		blockSize:   DefaultBlockSize,
+		cacheBlocks: DefaultCacheBlocks,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	_, size, err := r.fetch(0, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Reader = blockcache.NewReaderAt(rangeReaderAt{r}, size,
+		blockcache.WithBlockSize(r.blockSize),
+		blockcache.WithMaxBlocks(r.cacheBlocks),
+	)
+
+	return r, nil
+}
+
+// rangeReaderAt implements io.ReaderAt with one Range request per call.
+type rangeReaderAt struct {
+	r *Reader
+}
+
+func (ra rangeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	data, _, err := ra.r.fetch(off, int64(len(p)))
+	n := copy(p, data)
+	if err == nil && n < len(p) {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// fetch requests length bytes starting at offset and returns the data and
+// the total resource size reported by the server.
+func (r *Reader) fetch(offset, length int64) ([]byte, int64, error) {
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, r.url, http.NoBody)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		return nil, 0, ErrRangeNotSupported
+	default:
+		return nil, 0, fmt.Errorf("httpseek: unexpected status %s", resp.Status)
+	}
+
+	size, err := parseContentRangeSize(resp.Header.Get("Content-Range"))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, length))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return data, size, nil
+}
+
+// parseContentRangeSize extracts the complete length from a Content-Range
+// header of the form "bytes 0-1023/4096".
+func parseContentRangeSize(header string) (int64, error) {
+	_, total, ok := strings.Cut(header, "/")
+	if !ok || total == "*" {
+		return 0, fmt.Errorf("httpseek: invalid Content-Range %q", header)
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("httpseek: invalid Content-Range %q", header)
+	}
+	return size, nil
+}
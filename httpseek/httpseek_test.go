@@ -0,0 +1,112 @@
+package httpseek
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/llehouerou/go-faad2/blockcache"
+)
+
+func newTestServer(t *testing.T, content []byte) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		http.ServeContent(w, req, "audio.aac", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func testContent(size int) []byte {
+	content := make([]byte, size)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	return content
+}
+
+func TestReadAll(t *testing.T) {
+	content := testContent(10000)
+	srv, _ := newTestServer(t, content)
+
+	r, err := Open(context.Background(), srv.URL, WithBlockSize(1024))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	if r.Size() != int64(len(content)) {
+		t.Errorf("expected size %d, got %d", len(content), r.Size())
+	}
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Error("content mismatch")
+	}
+}
+
+func TestSeek(t *testing.T) {
+	content := testContent(10000)
+	srv, requests := newTestServer(t, content)
+
+	r, err := Open(context.Background(), srv.URL, WithBlockSize(1024), WithCacheBlocks(2))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	pos, err := r.Seek(5000, io.SeekStart)
+	if err != nil || pos != 5000 {
+		t.Fatalf("Seek failed: pos=%d, err=%v", pos, err)
+	}
+
+	buf := make([]byte, 100)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !bytes.Equal(buf, content[5000:5100]) {
+		t.Error("content mismatch after seek")
+	}
+
+	// Seeking back within a cached block must not issue a new request
+	before := requests.Load()
+	if _, err := r.Seek(-50, io.SeekCurrent); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if _, err := io.ReadFull(r, buf[:50]); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if requests.Load() != before {
+		t.Errorf("expected cached read, got %d new requests", requests.Load()-before)
+	}
+
+	pos, err = r.Seek(-10, io.SeekEnd)
+	if err != nil || pos != 9990 {
+		t.Fatalf("SeekEnd failed: pos=%d, err=%v", pos, err)
+	}
+
+	if _, err := r.Seek(-1, io.SeekStart); !errors.Is(err, blockcache.ErrInvalidSeek) {
+		t.Errorf("expected ErrInvalidSeek, got %v", err)
+	}
+}
+
+func TestRangeNotSupported(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("no ranges here"))
+	}))
+	defer srv.Close()
+
+	_, err := Open(context.Background(), srv.URL)
+	if !errors.Is(err, ErrRangeNotSupported) {
+		t.Errorf("expected ErrRangeNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package faad2
+
+import (
+	"fmt"
+	"time"
+)
+
+// Info is a snapshot of a reader's stream properties and progress, returned
+// by [ADTSReader.Info] and [FLVReader.Info].
+type Info struct {
+	// Container is the container format: "ADTS" or "FLV".
+	Container string
+
+	// Profile names the AAC object type, such as "AAC-LC" or "HE-AAC".
+	Profile string
+
+	// ObjectType is the MPEG-4 audio object type from the codec configuration:
+	// 5 (HE-AAC) or 29 (HE-AAC v2) when SBR or parametric stereo is signaled
+	// explicitly, even as an extension of an AAC-LC configuration.
+	ObjectType uint8
+
+	// SampleRate and Channels describe the PCM returned by Read.
+	SampleRate uint32
+	Channels   uint8
+
+	// Frames is the number of AAC frames read so far.
+	Frames int64
+
+	// Decoded is the duration of the frames read so far.
+	Decoded time.Duration
+
+	// Duration is the estimated total duration, or 0 if unknown. See
+	// [ADTSReader.Duration].
+	Duration time.Duration
+
+	// Bitrate is the average AAC bitrate in bits per second over the frames
+	// read so far, excluding container framing. It is 0 before any frame.
+	Bitrate int
+}
+
+// describe summarizes a reader for its String method.
+func (s *pcmStream) describe(name string, info Info) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return "faad2." + name + "(closed)"
+	}
+	return fmt.Sprintf("faad2.%s(%s, %d Hz, %d ch, frame %d, %v decoded, %d skipped)",
+		name, info.Profile, info.SampleRate, info.Channels, info.Frames,
+		info.Decoded.Round(time.Millisecond), s.framesSkipped)
+}
+
+// objectTypeNames maps MPEG-4 audio object types to profile names.
+var objectTypeNames = map[uint8]string{
+	1:  "AAC Main",
+	2:  "AAC-LC",
+	3:  "AAC SSR",
+	4:  "AAC LTP",
+	5:  "HE-AAC",
+	6:  "AAC Scalable",
+	17: "ER AAC-LC",
+	19: "ER AAC LTP",
+	23: "AAC-LD",
+	29: "HE-AAC v2",
+	39: "AAC-ELD",
+}
+
+// profileName returns the profile name of an audio object type.
+func profileName(objectType uint8) string {
+	if name, ok := objectTypeNames[objectType]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// info builds an Info snapshot from the stream state.
+func (s *pcmStream) info(container string, sampleRate uint32, channels uint8) Info {
+	info := Info{
+		Container:  container,
+		Profile:    profileName(s.asc.profileObjectType()),
+		ObjectType: s.asc.profileObjectType(),
+		SampleRate: sampleRate,
+		Channels:   channels,
+		Frames:     s.framesRead,
+	}
+
+	if rate := s.asc.sampleRate; rate > 0 && s.framesRead > 0 {
+		// Earlier parts of a concatenated stream count at their own rate
+		samples := (s.framesRead - s.partFrames) * coreFrameLength
+		info.Decoded = s.partsDecoded + samplesDuration(samples, int64(rate))
+		if info.Decoded > 0 {
+			info.Bitrate = int(float64(s.bytesRead*8) / info.Decoded.Seconds())
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,177 @@
+package faad2
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestADTSInfo(t *testing.T) {
+	ctx := context.Background()
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStream(20)))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	readAllSamples(t, reader.Read, 4096)
+	info := reader.Info()
+
+	if info.Container != "ADTS" || info.Profile != "AAC-LC" || info.ObjectType != 2 {
+		t.Errorf("unexpected codec info: %+v", info)
+	}
+	if info.SampleRate != reader.SampleRate() || info.Channels != reader.Channels() {
+		t.Errorf("format mismatch: %+v", info)
+	}
+	if info.Frames != 20 {
+		t.Errorf("expected 20 frames, got %d", info.Frames)
+	}
+	if want := 20 * 1024 * time.Second / 44100; info.Decoded != want {
+		t.Errorf("expected decoded duration %v, got %v", want, info.Decoded)
+	}
+
+	// Every payload is len(silentStereoFrame) bytes per 1024 samples
+	if want := len(silentStereoFrame) * 8 * 44100 / 1024; info.Bitrate != want {
+		t.Errorf("expected bitrate %d, got %d", want, info.Bitrate)
+	}
+}
+
+func TestProfileName(t *testing.T) {
+	if got := profileName(29); got != "HE-AAC v2" {
+		t.Errorf("expected HE-AAC v2, got %q", got)
+	}
+	if got := profileName(99); got != "unknown" {
+		t.Errorf("expected unknown, got %q", got)
+	}
+}
+
+func TestFLVInfo(t *testing.T) {
+	ctx := context.Background()
+
+	reader, err := OpenFLV(ctx, bytes.NewReader(buildTestFLVStream(10)))
+	if err != nil {
+		t.Fatalf("OpenFLV failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	readAllSamples(t, reader.Read, 4096)
+	info := reader.Info()
+
+	if info.Container != "FLV" || info.Profile != "AAC-LC" {
+		t.Errorf("unexpected codec info: %+v", info)
+	}
+	if info.Frames != reader.FramesRead() || info.Bitrate == 0 {
+		t.Errorf("unexpected progress: %+v", info)
+	}
+}
+
+func TestFLVInfoExplicitSBR(t *testing.T) {
+	ctx := context.Background()
+
+	// AAC-LC 24kHz with backward compatible SBR signaling to 48kHz
+	config := []byte{0x13, 0x10, 0x56, 0xE5, 0x98}
+	reader, err := OpenFLV(ctx, bytes.NewReader(buildTestFLVStreamWith(config, 5)))
+	if err != nil {
+		t.Fatalf("OpenFLV failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	info := reader.Info()
+	if info.Profile != "HE-AAC" || info.ObjectType != 5 {
+		t.Errorf("expected HE-AAC before decoding, got %q (%d)", info.Profile, info.ObjectType)
+	}
+	if info.SampleRate != 48000 {
+		t.Errorf("expected 48000 Hz output before decoding, got %d", info.SampleRate)
+	}
+
+	readAllSamples(t, reader.Read, 4096)
+	info = reader.Info()
+	if want := 5 * 1024 * time.Second / 24000; info.Decoded != want {
+		t.Errorf("expected decoded duration %v at the core rate, got %v", want, info.Decoded)
+	}
+}
+
+func TestReaderString(t *testing.T) {
+	ctx := context.Background()
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStream(20)))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	readAllSamples(t, reader.Read, 4096)
+
+	want := "faad2.ADTSReader(AAC-LC, 44100 Hz, 2 ch, frame 20, 464ms decoded, 0 skipped)"
+	if got := reader.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	reader.Close(ctx)
+	if got := reader.String(); got != "faad2.ADTSReader(closed)" {
+		t.Errorf("after Close: got %q", got)
+	}
+}
+
+func TestDecoderString(t *testing.T) {
+	ctx := context.Background()
+
+	dec, err := NewDecoder(ctx)
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	if got := dec.String(); got != "faad2.Decoder(uninitialized)" {
+		t.Errorf("before Init: got %q", got)
+	}
+
+	if err := dec.Init(ctx, []byte{0x12, 0x10}); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if _, err := dec.Decode(ctx, silentStereoFrame); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if got, want := dec.String(), "faad2.Decoder(44100 Hz, 2 ch, 1 frames)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	dec.Close(ctx)
+	if got := dec.String(); got != "faad2.Decoder(closed)" {
+		t.Errorf("after Close: got %q", got)
+	}
+}
+
+func TestADTSDurationHint(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(100)
+
+	// Only the first frames are available, as on a slow download
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream[:len(stream)/10]), WithSizeHint(int64(len(stream))))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	want := 100 * 1024 * time.Second / 44100
+	if got := reader.Duration(); got < want-time.Millisecond || got > want+time.Millisecond {
+		t.Errorf("expected about %v after opening, got %v", want, got)
+	}
+
+	readAllSamples(t, reader.Read, 4096)
+	if got := reader.Info().Duration; got < want-time.Millisecond || got > want+time.Millisecond {
+		t.Errorf("expected about %v, got %v", want, got)
+	}
+}
+
+func TestADTSDurationWithoutHint(t *testing.T) {
+	ctx := context.Background()
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStream(10)))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	if got := reader.Duration(); got != 0 {
+		t.Errorf("expected 0 without size hint, got %v", got)
+	}
+}
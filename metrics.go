@@ -0,0 +1,33 @@
+package faad2
+
+import "time"
+
+// Metrics receives decoder lifecycle and performance events, for exporting to
+// a monitoring system. Set it with [WithMetrics]; the faad2prom package
+// provides a Prometheus implementation.
+//
+// Methods are called synchronously from decoder methods, possibly from many
+// goroutines at once, and must be safe for concurrent use and fast.
+type Metrics interface {
+	// DecoderOpened is called when a decoder is created.
+	DecoderOpened()
+
+	// DecoderClosed is called when a decoder is closed.
+	DecoderClosed()
+
+	// FrameDecoded is called after a frame is decoded successfully, with the
+	// time spent in Decode and the number of samples produced.
+	FrameDecoded(elapsed time.Duration, samples int)
+
+	// DecodeFailed is called when Decode returns an error. Use [Category] to
+	// classify it.
+	DecodeFailed(err error)
+}
+
+// WithMetrics reports decoder events to m. Readers pass it on to their
+// decoder, so the events of every frame they decode are included.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
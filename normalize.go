@@ -0,0 +1,96 @@
+package faad2
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+)
+
+// closingPCMReader is a reader opened for a peak scan.
+type closingPCMReader interface {
+	Read(ctx context.Context, pcm []int16) (int, error)
+	Close(ctx context.Context) error
+}
+
+// peakGain computes the gain that brings the peak of the stream in r to the
+// target set with WithPeakNormalization, by decoding the whole stream with
+// open. r must be an io.ReadSeeker; it is rewound to its starting position.
+func peakGain(
+	ctx context.Context,
+	r io.Reader,
+	target float64,
+	open func(context.Context, io.Reader, ...Option) (closingPCMReader, error),
+	opts []Option,
+) (float64, error) {
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		return 0, ErrNotSeekable
+	}
+	start, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, ErrNotSeekable
+	}
+
+	// Scan with the caller's options, without normalizing recursively,
+	// copying the stream twice, reporting the throwaway pass to the caller's
+	// metrics and callbacks, or decoding ahead
+	scanOpts := append(append([]Option(nil), opts...), func(o *options) {
+		o.normalizePeak = 0
+		o.tee = nil
+		o.metrics = nil
+		o.onFormatChange = nil
+		o.lookAhead = false
+	})
+	reader, err := open(ctx, rs, scanOpts...)
+	if err != nil {
+		return 0, err
+	}
+	peak, err := scanPeak(ctx, reader)
+	reader.Close(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := rs.Seek(start, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	if peak == 0 {
+		return 1, nil
+	}
+	return target * math.MaxInt16 / float64(peak), nil
+}
+
+// scanPeak reads r to the end and returns the largest absolute sample value.
+func scanPeak(ctx context.Context, r closingPCMReader) (int, error) {
+	pcm := make([]int16, 8192)
+	peak := 0
+	for {
+		n, err := r.Read(ctx, pcm)
+		for _, s := range pcm[:n] {
+			peak = max(peak, abs(int(s)))
+		}
+		if errors.Is(err, io.EOF) {
+			return peak, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// applyGain scales samples in place, clipping to the 16-bit range.
+func applyGain(samples []int16, gain float64) {
+	for i, s := range samples {
+		v := math.Round(float64(s) * gain)
+		samples[i] = int16(max(math.MinInt16, min(math.MaxInt16, v)))
+	}
+}
@@ -0,0 +1,114 @@
+package faad2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestApplyGain(t *testing.T) {
+	samples := []int16{100, -100, 20000, -20000, 0}
+	applyGain(samples, 2)
+
+	want := []int16{200, -200, math.MaxInt16, math.MinInt16, 0}
+	for i := range want {
+		if samples[i] != want[i] {
+			t.Errorf("sample %d: got %d, want %d", i, samples[i], want[i])
+		}
+	}
+}
+
+// fakePCMReader serves fixed samples for peak scanning.
+type fakePCMReader struct {
+	pcm []int16
+}
+
+func (r *fakePCMReader) Read(_ context.Context, pcm []int16) (int, error) {
+	if len(r.pcm) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(pcm, r.pcm)
+	r.pcm = r.pcm[n:]
+	return n, nil
+}
+
+func (r *fakePCMReader) Close(context.Context) error {
+	return nil
+}
+
+func TestPeakGain(t *testing.T) {
+	ctx := context.Background()
+	src := bytes.NewReader([]byte("0123456789"))
+	if _, err := src.Seek(3, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	open := func(_ context.Context, r io.Reader, _ ...Option) (closingPCMReader, error) {
+		_, _ = io.ReadAll(r)
+		return &fakePCMReader{pcm: []int16{10, -16384, 300}}, nil
+	}
+
+	gain, err := peakGain(ctx, src, 0.5, open, nil)
+	if err != nil {
+		t.Fatalf("peakGain failed: %v", err)
+	}
+	if want := 0.5 * math.MaxInt16 / 16384; math.Abs(gain-want) > 1e-9 {
+		t.Errorf("expected gain %v, got %v", want, gain)
+	}
+	if pos, _ := src.Seek(0, io.SeekCurrent); pos != 3 {
+		t.Errorf("expected reader rewound to 3, got %d", pos)
+	}
+}
+
+func TestPeakNormalizationSilence(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(10)
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream), WithPeakNormalization(0.9))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	// Silence has no peak and is left unchanged
+	if reader.gain != 1 {
+		t.Errorf("expected unity gain for silence, got %v", reader.gain)
+	}
+	if readAllSamples(t, reader.Read, 4096) == 0 {
+		t.Error("expected samples after the peak scan rewound the stream")
+	}
+}
+
+func TestPeakNormalizationNotSeekable(t *testing.T) {
+	ctx := context.Background()
+	r := io.MultiReader(bytes.NewReader(buildTestADTSStream(5)))
+
+	_, err := OpenADTS(ctx, r, WithPeakNormalization(0.9))
+	if !errors.Is(err, ErrNotSeekable) {
+		t.Errorf("expected ErrNotSeekable, got %v", err)
+	}
+}
+
+func TestPeakNormalizationMetrics(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(10)
+	m := &countingMetrics{}
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream), WithPeakNormalization(0.9), WithMetrics(m))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	readAllSamples(t, reader.Read, 4096)
+	reader.Close(ctx)
+
+	// The scan pass is not reported, so each frame is counted once
+	if m.opened != 1 || m.closed != 1 {
+		t.Errorf("expected 1 open and 1 close, got %d and %d", m.opened, m.closed)
+	}
+	if m.frames != 10 {
+		t.Errorf("expected 10 frames, got %d", m.frames)
+	}
+}
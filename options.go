@@ -0,0 +1,334 @@
+package faad2
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"time"
+)
+
+// Option configures a [Decoder] or a reader such as [ADTSReader].
+//
+// Readers pass their options on to the decoder they create internally, so
+// decoder options can be given directly to [OpenADTS].
+type Option func(*options)
+
+type options struct {
+	limits            Limits
+	dedicatedModule   bool
+	lookAhead         bool
+	skipCorruptFrames bool
+	repeatConcealment bool
+	deferredPriming   bool
+	onFormatChange    func(sampleRate uint32, channels uint8)
+	allowTruncated    bool
+	extractChannel    bool
+	channel           int
+	metrics           Metrics
+	bufferSize        int
+	bufferSizeSet     bool
+	decodeTimeout     time.Duration
+	normalizePeak     float64
+	sizeHint          int64
+	readTimeout       time.Duration
+	frameTransform    func(frame []byte) ([]byte, error)
+	baseContext       context.Context
+	tee               io.Writer
+
+	// Set by Scanner: a module the decoder uses without owning it, and a
+	// buffer reused in front of the underlying reader.
+	module *wasmContext
+	buffer *bufio.Reader
+
+	startOffset time.Duration
+	endOffset   time.Duration
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Limits bounds the resources used while parsing and decoding untrusted input.
+//
+// A zero value for any field means no limit.
+type Limits struct {
+	// MaxFrameSize is the maximum size in bytes of a single AAC frame,
+	// including any container framing such as the ADTS header.
+	MaxFrameSize int
+
+	// MaxDecodeMemory is the maximum size in bytes of the WASM linear memory
+	// used by the decoder. It is checked after each decode: linear memory
+	// never shrinks, so once the limit is exceeded every later decode returns
+	// [ErrLimitExceeded] and the decoder must be replaced. Setting it implies
+	// [WithDedicatedModule], so that only this decoder is affected.
+	MaxDecodeMemory uint32
+}
+
+// WithLimits sets resource limits enforced during parsing and decoding.
+//
+// Exceeding a limit returns [ErrLimitExceeded].
+func WithLimits(limits Limits) Option {
+	return func(o *options) {
+		o.limits = limits
+		if limits.MaxDecodeMemory > 0 {
+			o.dedicatedModule = true
+		}
+	}
+}
+
+// WithDedicatedModule gives each decoder its own instance of the WASM module.
+//
+// Decoders normally share one module instance, and calls into it are
+// serialized. A dedicated instance lets the decoder run in parallel with other
+// decoders, at the cost of its own WASM linear memory (16 MiB or more).
+func WithDedicatedModule() Option {
+	return func(o *options) {
+		o.dedicatedModule = true
+	}
+}
+
+// WithLookAhead makes readers decode the next frame on a helper goroutine
+// while the caller consumes the current one.
+//
+// This hides decode latency in real-time playback on slow machines. The
+// underlying reader is read from the helper goroutine, and Close waits for
+// any frame being decoded ahead.
+func WithLookAhead() Option {
+	return func(o *options) {
+		o.lookAhead = true
+	}
+}
+
+// WithSkipCorruptFrames makes readers replace frames that cannot be parsed or
+// decoded with silence of the same duration as the previous frame, instead of
+// returning an error and stopping the stream.
+//
+// Skipped frames are counted by the reader's FramesSkipped method. See
+// [WithRepeatConcealment] to repeat the previous frame instead of silence.
+func WithSkipCorruptFrames() Option {
+	return func(o *options) {
+		o.skipCorruptFrames = true
+	}
+}
+
+// WithRepeatConcealment makes [WithSkipCorruptFrames] replace a corrupt frame
+// with a copy of the previous frame rather than silence, which is less audible
+// for isolated errors. Consecutive corrupt frames after the first are still
+// replaced with silence, so a long outage does not loop audio.
+//
+// The readers' Concealment method reports how many frames were concealed
+// each way.
+func WithRepeatConcealment() Option {
+	return func(o *options) {
+		o.repeatConcealment = true
+	}
+}
+
+// WithFormatChange sets a callback invoked from Read when the reader's output
+// format changes after opening, for example when SBR (HE-AAC) is discovered in
+// the bitstream and the output sample rate doubles. Players should reconfigure
+// the audio device before playing the samples returned by that Read.
+//
+// The callback runs while the reader is locked, so it must not call the
+// reader's methods; the new format is passed as arguments.
+func WithFormatChange(fn func(sampleRate uint32, channels uint8)) Option {
+	return func(o *options) {
+		o.onFormatChange = fn
+	}
+}
+
+// WithAllowTruncated makes readers treat a stream that ends partway through a
+// frame as a normal end of stream, decoding every complete frame before the
+// cut. Without it, Read returns [ErrTruncated].
+func WithAllowTruncated() Option {
+	return func(o *options) {
+		o.allowTruncated = true
+	}
+}
+
+// WithChannel makes readers output only channel i (0 for left, 1 for right)
+// of the decoded audio, so Read returns mono samples. The reader's Channels
+// method then reports 1.
+//
+// Opening the reader returns [ErrInvalidChannel] if the stream has no
+// channel i. Mono streams decode to two identical channels (see
+// [Decoder.Channels]), so channels 0 and 1 are both valid for them.
+func WithChannel(i int) Option {
+	return func(o *options) {
+		o.extractChannel = true
+		o.channel = i
+	}
+}
+
+// WithBufferSize sets the size in bytes of the read buffer that [OpenADTS]
+// places in front of the underlying reader, so that small header reads do not
+// each reach a file or socket. Defaults to 32 KiB; 0 disables buffering.
+//
+// Buffering reads ahead of the frame being decoded. Disable it if the
+// underlying reader is used again after the ADTS reader, or if it is already
+// buffered or in memory.
+func WithBufferSize(size int) Option {
+	return func(o *options) {
+		o.bufferSize = max(size, 0)
+		o.bufferSizeSet = true
+	}
+}
+
+// WithDecodeTimeout aborts a Decode call whose WASM execution takes longer
+// than timeout, independently of the context passed to Decode, so that a
+// pathological frame cannot make a worker spin forever. Decode then returns
+// [ErrDecodeTimeout] and the decoder must be closed and replaced.
+//
+// Aborting execution requires a private module instance in a runtime that
+// watches contexts, so this implies [WithDedicatedModule]. In that runtime,
+// cancelling the context passed to Decode also aborts the call and leaves
+// the decoder unusable.
+func WithDecodeTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.decodeTimeout = timeout
+		if timeout > 0 {
+			o.dedicatedModule = true
+		}
+	}
+}
+
+// WithPeakNormalization scales the output of a reader so that its loudest
+// sample reaches target, a fraction of full scale in (0, 1], for systems
+// that need consistent levels such as voicemail or IVR prompts. Quiet
+// recordings are amplified and loud ones attenuated.
+//
+// The peak is found by decoding the whole stream when the reader is opened,
+// so the underlying reader must implement [io.Seeker] (opening returns
+// [ErrNotSeekable] otherwise) and opening takes as long as a full decode.
+// Values above 1 are treated as 1; zero or negative values disable
+// normalization.
+func WithPeakNormalization(target float64) Option {
+	return func(o *options) {
+		o.normalizePeak = min(max(target, 0), 1)
+	}
+}
+
+// WithSizeHint gives the total size in bytes of the stream, such as an HTTP
+// Content-Length, so that [ADTSReader.Duration] can estimate the duration of
+// a stream that cannot be seeked or scanned in advance.
+func WithSizeHint(size int64) Option {
+	return func(o *options) {
+		o.sizeHint = size
+	}
+}
+
+// WithReadTimeout makes readers fail with [ErrReadTimeout] when reading one
+// frame from the underlying reader takes longer than timeout, so a stalled
+// network stream surfaces an error instead of blocking Read forever.
+//
+// If the underlying reader has a SetReadDeadline method, as [net.Conn] and
+// [os.File] do, a deadline is set for each frame and the stream can be read
+// again after a timeout, resynchronizing on the next frame. For other
+// readers the blocked read cannot be interrupted: it is abandoned, and every
+// later Read returns ErrReadTimeout.
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.readTimeout = timeout
+	}
+}
+
+// WithTee makes readers copy the compressed stream to w as they read it, so a
+// radio recorder can save the broadcast while playing it without fetching it
+// twice. Every byte read from the underlying reader is written, container
+// included, in the order read; buffering means the copy may run ahead of the
+// decoded audio. A write error stops reading and is returned by Read.
+//
+// The scan pass of [WithPeakNormalization] is not copied.
+func WithTee(w io.Writer) Option {
+	return func(o *options) {
+		o.tee = w
+	}
+}
+
+// teeReader returns r copying to the WithTee writer, if any.
+func (o *options) teeReader(r io.Reader) io.Reader {
+	if o.tee == nil {
+		return r
+	}
+	return io.TeeReader(r, o.tee)
+}
+
+// transform applies the WithFrameTransform function, if any, to frame.
+func (o *options) transform(frame []byte) ([]byte, error) {
+	if o.frameTransform == nil {
+		return frame, nil
+	}
+	return o.frameTransform(frame)
+}
+
+// WithFrameTransform sets a function applied to each AAC frame after it is
+// read from the container and before it is decoded, for example to remove a
+// custom obfuscation layer. For ADTS streams the frame excludes the header.
+//
+// The returned slice is decoded in place of frame; fn may modify frame and
+// return it. An error from fn is returned by Read. Wrap [ErrDecodeFailed] to
+// have [WithSkipCorruptFrames] replace the frame with silence instead.
+func WithFrameTransform(fn func(frame []byte) ([]byte, error)) Option {
+	return func(o *options) {
+		o.frameTransform = fn
+	}
+}
+
+// WithStartOffset makes readers skip the audio before offset, measured from
+// the start of the stream. The skipped frames are still read and decoded,
+// as the supported containers cannot seek, so the first Read takes longer.
+func WithStartOffset(offset time.Duration) Option {
+	return func(o *options) {
+		o.startOffset = max(offset, 0)
+	}
+}
+
+// WithEndOffset makes readers return io.EOF once the audio up to offset,
+// measured from the start of the stream, has been read. 0 reads to the end
+// of the stream.
+func WithEndOffset(offset time.Duration) Option {
+	return func(o *options) {
+		o.endOffset = max(offset, 0)
+	}
+}
+
+// WithBaseContext sets a context used by the decoder in place of
+// context.Background or context.TODO passed to its methods, for call sites
+// that cannot thread the original context through. Cancelling base makes
+// Init, Decode and the readers' Read fail with its error.
+//
+// Close always uses the context it is given, so that resources are released
+// after base is cancelled.
+func WithBaseContext(base context.Context) Option {
+	return func(o *options) {
+		o.baseContext = base
+	}
+}
+
+// ownsModule reports whether a decoder created with these options has a
+// module of its own, closed with the decoder.
+func (o *options) ownsModule() bool {
+	return o.dedicatedModule && o.module == nil
+}
+
+// context returns ctx, or the WithBaseContext context if ctx is nil,
+// context.Background or context.TODO.
+func (o *options) context(ctx context.Context) context.Context {
+	if o.baseContext != nil && (ctx == nil || ctx == context.Background() || ctx == context.TODO()) {
+		return o.baseContext
+	}
+	return ctx
+}
+
+// WithDeferredPriming makes [OpenADTS] only parse the first frame and
+// initialize the decoder, leaving the first decode to the first Read. Open
+// then does no decoding work, which keeps its latency predictable.
+func WithDeferredPriming() Option {
+	return func(o *options) {
+		o.deferredPriming = true
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !(386 || amd64 || arm || arm64 || loong64 || mips64le || mipsle || ppc64le || riscv64 || wasm)
+
+package faad2
+
+import "encoding/binary"
+
+// decodePCM16 converts little-endian 16-bit PCM bytes to samples.
+func decodePCM16(dst []int16, src []byte) {
+	for i := range dst {
+		dst[i] = int16(binary.LittleEndian.Uint16(src[i*2:])) //nolint:gosec // intentional bit reinterpretation
+	}
+}
@@ -0,0 +1,16 @@
+//go:build 386 || amd64 || arm || arm64 || loong64 || mips64le || mipsle || ppc64le || riscv64 || wasm
+
+package faad2
+
+import "unsafe"
+
+// decodePCM16 converts little-endian 16-bit PCM bytes to samples.
+//
+// On little-endian architectures the byte layout already matches []int16,
+// so the conversion is a single memory copy.
+func decodePCM16(dst []int16, src []byte) {
+	if len(dst) == 0 {
+		return
+	}
+	copy(unsafe.Slice((*byte)(unsafe.Pointer(&dst[0])), len(dst)*2), src)
+}
@@ -0,0 +1,29 @@
+package faad2
+
+import "testing"
+
+func TestDecodePCM16(t *testing.T) {
+	src := []byte{0x00, 0x00, 0x01, 0x00, 0xFF, 0xFF, 0x00, 0x80, 0xFF, 0x7F, 0x34, 0x12}
+	expected := []int16{0, 1, -1, -32768, 32767, 0x1234}
+
+	dst := make([]int16, len(expected))
+	decodePCM16(dst, src)
+
+	for i := range expected {
+		if dst[i] != expected[i] {
+			t.Errorf("sample %d: expected %d, got %d", i, expected[i], dst[i])
+		}
+	}
+
+	// Empty input must not panic
+	decodePCM16(nil, nil)
+}
+
+func BenchmarkDecodePCM16(b *testing.B) {
+	src := make([]byte, 2048*2*2)
+	dst := make([]int16, 2048*2)
+	b.SetBytes(int64(len(src)))
+	for b.Loop() {
+		decodePCM16(dst, src)
+	}
+}
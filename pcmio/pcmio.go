@@ -0,0 +1,233 @@
+// Package pcmio writes decoded PCM to sinks: files, any [io.Writer], an
+// in-memory ring buffer, or nowhere.
+//
+// [Copy] drains a reader into a sink, handling short reads and the final
+// flush:
+//
+//	out, _ := pcmio.CreateFile("out.raw", pcmio.FormatFloat32)
+//	defer out.Close()
+//	_, err := pcmio.Copy(ctx, out, reader)
+package pcmio
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	faad2 "github.com/llehouerou/go-faad2"
+)
+
+// copyBufferSize is the number of samples read per Read call by [Copy].
+const copyBufferSize = 8192
+
+// Common output formats. Sinks that take a format also accept any other
+// interleaved [faad2.PCMFormat].
+var (
+	// FormatS16LE is 16-bit signed little-endian, the layout of WAV files.
+	FormatS16LE = faad2.PCMFormat{BitDepth: 16, Encoding: faad2.EncodingSigned}
+
+	// FormatFloat32 is 32-bit little-endian float, the usual format of
+	// audio APIs and DSP libraries.
+	FormatFloat32 = faad2.PCMFormat{BitDepth: 32, Encoding: faad2.EncodingFloat}
+)
+
+// Sink receives interleaved 16-bit PCM, as returned by Read.
+type Sink interface {
+	WritePCM(pcm []int16) error
+}
+
+// Flusher is implemented by sinks that buffer output. [Copy] calls Flush
+// after the last write.
+type Flusher interface {
+	Flush() error
+}
+
+// Copy reads src until [io.EOF] and writes every sample to dst, then flushes
+// dst if it is a [Flusher]. It returns the number of samples copied and the
+// first error other than io.EOF.
+func Copy(ctx context.Context, dst Sink, src faad2.PCMReader) (int64, error) {
+	pcm := make([]int16, copyBufferSize)
+	var written int64
+	for {
+		n, err := src.Read(ctx, pcm)
+		if n > 0 {
+			if werr := dst.WritePCM(pcm[:n]); werr != nil {
+				return written, werr
+			}
+			written += int64(n)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+
+	if f, ok := dst.(Flusher); ok {
+		if err := f.Flush(); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// Writer is a buffered [Sink] converting samples to a [faad2.PCMFormat] and
+// writing them to an [io.Writer]. Call [Writer.Flush] when done.
+//
+// A Writer is not safe for concurrent use.
+type Writer struct {
+	w      *bufio.Writer
+	format faad2.PCMFormat
+	buf    []byte
+}
+
+// NewWriter creates a Writer converting samples to format.
+//
+// Returns [faad2.ErrInvalidPCMFormat] if format is unsupported or planar,
+// which requires whole buffers per channel rather than a stream.
+func NewWriter(w io.Writer, format faad2.PCMFormat) (*Writer, error) {
+	if err := checkFormat(format); err != nil {
+		return nil, err
+	}
+	return &Writer{w: bufio.NewWriter(w), format: format}, nil
+}
+
+// checkFormat returns [faad2.ErrInvalidPCMFormat] if format cannot be
+// streamed.
+func checkFormat(format faad2.PCMFormat) error {
+	if format.Planar {
+		return faad2.ErrInvalidPCMFormat
+	}
+	return format.Validate()
+}
+
+// WritePCM converts pcm and writes it to the buffer.
+func (w *Writer) WritePCM(pcm []int16) error {
+	// Interleaved conversion works sample by sample, so one channel will do
+	var err error
+	w.buf, err = w.format.Append(w.buf[:0], pcm, 1)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(w.buf)
+	return err
+}
+
+// Flush writes any buffered data to the underlying [io.Writer].
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// File is a [Writer] to a file it owns.
+type File struct {
+	*Writer
+	f *os.File
+}
+
+// CreateFile creates or truncates the named file and returns a sink writing
+// raw PCM in format to it. Call [File.Close] when done.
+//
+// Returns [faad2.ErrInvalidPCMFormat] for a format rejected by [NewWriter],
+// or the error from [os.Create].
+func CreateFile(name string, format faad2.PCMFormat) (*File, error) {
+	if err := checkFormat(format); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &File{Writer: &Writer{w: bufio.NewWriter(f), format: format}, f: f}, nil
+}
+
+// Close flushes buffered data and closes the file.
+func (f *File) Close() error {
+	err := f.Flush()
+	if cerr := f.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Ring is a fixed-capacity [Sink] keeping the most recent samples, for
+// handing PCM to an audio callback running on another goroutine. When full,
+// writes overwrite the oldest samples.
+//
+// A Ring is safe for concurrent use.
+type Ring struct {
+	mu      sync.Mutex
+	buf     []int16
+	start   int // index of the oldest sample
+	n       int // buffered samples
+	dropped int64
+}
+
+// NewRing creates a Ring holding up to size samples. Use a multiple of the
+// channel count so that overwrites drop whole frames.
+func NewRing(size int) *Ring {
+	return &Ring{buf: make([]int16, max(size, 1))}
+}
+
+// WritePCM appends pcm, overwriting the oldest samples if the ring is full.
+// It never fails.
+func (r *Ring) WritePCM(pcm []int16) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Only the newest samples can survive a write larger than the ring
+	if excess := len(pcm) - len(r.buf); excess > 0 {
+		r.dropped += int64(excess)
+		pcm = pcm[excess:]
+	}
+	if overflow := r.n + len(pcm) - len(r.buf); overflow > 0 {
+		r.start = (r.start + overflow) % len(r.buf)
+		r.n -= overflow
+		r.dropped += int64(overflow)
+	}
+
+	end := (r.start + r.n) % len(r.buf)
+	copied := copy(r.buf[end:], pcm)
+	copy(r.buf, pcm[copied:])
+	r.n += len(pcm)
+	return nil
+}
+
+// Read moves up to len(pcm) of the oldest samples into pcm and returns their
+// number. It does not block; 0 means the ring is empty.
+func (r *Ring) Read(pcm []int16) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := min(len(pcm), r.n)
+	copied := copy(pcm[:n], r.buf[r.start:])
+	copy(pcm[copied:n], r.buf)
+	r.start = (r.start + n) % len(r.buf)
+	r.n -= n
+	return n
+}
+
+// Len returns the number of buffered samples.
+func (r *Ring) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.n
+}
+
+// Dropped returns the number of samples overwritten before being read.
+func (r *Ring) Dropped() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropped
+}
+
+// Discard is a [Sink] on which all writes succeed without doing anything,
+// for measuring decode speed.
+var Discard Sink = discard{}
+
+type discard struct{}
+
+func (discard) WritePCM([]int16) error { return nil }
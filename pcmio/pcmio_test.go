@@ -0,0 +1,160 @@
+package pcmio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	faad2 "github.com/llehouerou/go-faad2"
+)
+
+// chunkReader serves samples in short reads, returning io.EOF with the last
+// chunk.
+type chunkReader struct {
+	samples []int16
+	chunk   int
+}
+
+func (r *chunkReader) Read(_ context.Context, pcm []int16) (int, error) {
+	n := copy(pcm[:min(len(pcm), r.chunk)], r.samples)
+	r.samples = r.samples[n:]
+	if len(r.samples) == 0 {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *chunkReader) SampleRate() uint32 { return 44100 }
+func (r *chunkReader) Channels() uint8    { return 2 }
+
+// errReader fails after serving its samples.
+type errReader struct {
+	chunkReader
+	err error
+}
+
+func (r *errReader) Read(ctx context.Context, pcm []int16) (int, error) {
+	n, err := r.chunkReader.Read(ctx, pcm)
+	if errors.Is(err, io.EOF) {
+		err = r.err
+	}
+	return n, err
+}
+
+func TestCopyWriter(t *testing.T) {
+	var out bytes.Buffer
+	w, err := NewWriter(&out, FormatS16LE)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+
+	src := &chunkReader{samples: []int16{1, -1, 256, 0x7FFF, -0x8000}, chunk: 2}
+	n, err := Copy(context.Background(), w, src)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if n != 5 {
+		t.Errorf("expected 5 samples copied, got %d", n)
+	}
+
+	want := []byte{0x01, 0x00, 0xFF, 0xFF, 0x00, 0x01, 0xFF, 0x7F, 0x00, 0x80}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("got % X, want % X", out.Bytes(), want)
+	}
+}
+
+func TestCopyReadError(t *testing.T) {
+	errBroken := errors.New("broken")
+	src := &errReader{chunkReader: chunkReader{samples: []int16{1, 2, 3}, chunk: 2}, err: errBroken}
+
+	ring := NewRing(16)
+	n, err := Copy(context.Background(), ring, src)
+	if !errors.Is(err, errBroken) {
+		t.Errorf("expected read error, got %v", err)
+	}
+	if n != 3 || ring.Len() != 3 {
+		t.Errorf("expected 3 samples copied before the error, got %d (ring %d)", n, ring.Len())
+	}
+}
+
+func TestNewWriterInvalidFormat(t *testing.T) {
+	formats := []faad2.PCMFormat{
+		{BitDepth: 12},
+		{BitDepth: 16, Planar: true},
+	}
+	for _, format := range formats {
+		if _, err := NewWriter(io.Discard, format); !errors.Is(err, faad2.ErrInvalidPCMFormat) {
+			t.Errorf("%v: expected ErrInvalidPCMFormat, got %v", format, err)
+		}
+	}
+}
+
+func TestCreateFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "out.raw")
+	f, err := CreateFile(name, FormatFloat32)
+	if err != nil {
+		t.Fatalf("CreateFile failed: %v", err)
+	}
+	if _, err := Copy(context.Background(), f, &chunkReader{samples: []int16{0x4000, -0x8000}, chunk: 1}); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	want := []byte{0x00, 0x00, 0x00, 0x3F, 0x00, 0x00, 0x80, 0xBF}
+	if !bytes.Equal(data, want) {
+		t.Errorf("got % X, want % X", data, want)
+	}
+}
+
+func TestRing(t *testing.T) {
+	ring := NewRing(4)
+
+	_ = ring.WritePCM([]int16{1, 2, 3})
+	pcm := make([]int16, 2)
+	if n := ring.Read(pcm); n != 2 || !slices.Equal(pcm, []int16{1, 2}) {
+		t.Fatalf("expected [1 2], got %v", pcm[:n])
+	}
+
+	// Wraps around the end of the buffer and overwrites the oldest sample
+	_ = ring.WritePCM([]int16{4, 5, 6, 7})
+	if ring.Dropped() != 1 {
+		t.Errorf("expected 1 dropped sample, got %d", ring.Dropped())
+	}
+	pcm = make([]int16, 8)
+	if n := ring.Read(pcm); n != 4 || !slices.Equal(pcm[:n], []int16{4, 5, 6, 7}) {
+		t.Errorf("expected [4 5 6 7], got %v", pcm[:n])
+	}
+	if n := ring.Read(pcm); n != 0 {
+		t.Errorf("expected empty ring, got %d samples", n)
+	}
+
+	// A write larger than the ring keeps its newest samples
+	_ = ring.WritePCM([]int16{10, 11, 12, 13, 14, 15})
+	if n := ring.Read(pcm); n != 4 || !slices.Equal(pcm[:n], []int16{12, 13, 14, 15}) {
+		t.Errorf("expected [12 13 14 15], got %v", pcm[:n])
+	}
+	if ring.Dropped() != 3 {
+		t.Errorf("expected 3 dropped samples, got %d", ring.Dropped())
+	}
+}
+
+func TestCopyDiscard(t *testing.T) {
+	n, err := Copy(context.Background(), Discard, &chunkReader{samples: make([]int16, 20000), chunk: 4096})
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if n != 20000 {
+		t.Errorf("expected 20000 samples copied, got %d", n)
+	}
+}
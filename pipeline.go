@@ -0,0 +1,309 @@
+package faad2
+
+import (
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// defaultSegmentFrames is the default number of frames per pipeline segment.
+const defaultSegmentFrames = 64
+
+// Pipeline decodes an AAC stream on several decoder instances concurrently
+// while preserving output order.
+//
+// Frames are demuxed on one goroutine and split into segments of consecutive
+// frames. Each segment is decoded by one worker, primed with the last frame of
+// the previous segment so that the overlap-add state matches a sequential
+// decode. This gives faster-than-realtime decoding on multicore machines for
+// offline work such as loudness scanning; it is not intended for playback.
+//
+// Each worker uses a dedicated WASM module instance (see [WithDedicatedModule]).
+type Pipeline struct {
+	// Workers is the number of decoders running in parallel.
+	// Defaults to runtime.NumCPU().
+	Workers int
+
+	// SegmentFrames is the number of frames decoded by a worker at a time.
+	// Larger segments reduce priming overhead. Defaults to 64.
+	SegmentFrames int
+
+	// Options are passed to every worker decoder.
+	Options []Option
+}
+
+// DecodedSegment is a run of decoded PCM produced by a [Pipeline].
+type DecodedSegment struct {
+	// FirstFrame is the index of the first frame in the segment.
+	FirstFrame int64
+
+	// Frames is the number of frames in the segment.
+	Frames int
+
+	// PCM holds interleaved samples for all frames in the segment.
+	PCM []int16
+
+	// SampleRate and Channels describe the decoder output.
+	SampleRate uint32
+	Channels   uint8
+}
+
+type pipelineJob struct {
+	index   int
+	first   int64
+	preroll []byte
+	frames  [][]byte
+}
+
+type pipelineResult struct {
+	index int
+	seg   DecodedSegment
+	err   error
+}
+
+// Decode decodes frames returned by next, which must return [io.EOF] after
+// the last frame, and calls emit with each segment in stream order.
+//
+// config is the AudioSpecificConfig used to initialize every worker. Decode
+// returns the first error from next, a decoder, or emit, or the context error
+// if ctx is cancelled before every segment is emitted.
+func (p Pipeline) Decode(
+	ctx context.Context,
+	config []byte,
+	next func() ([]byte, error),
+	emit func(DecodedSegment) error,
+) error {
+	workers := p.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	segmentFrames := p.SegmentFrames
+	if segmentFrames <= 0 {
+		segmentFrames = defaultSegmentFrames
+	}
+
+	opts := append(append([]Option(nil), p.Options...), WithDedicatedModule())
+	decoders := make([]*Decoder, 0, workers)
+	defer func() {
+		for _, dec := range decoders {
+			dec.Close(ctx)
+		}
+	}()
+	for range workers {
+		dec, err := NewDecoder(ctx, opts...)
+		if err != nil {
+			return err
+		}
+		decoders = append(decoders, dec)
+		if err := dec.Init(ctx, config); err != nil {
+			return err
+		}
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan pipelineJob)
+	results := make(chan pipelineResult, workers)
+
+	// Bound the number of segments in flight so a slow worker cannot make
+	// the reorder buffer grow without limit.
+	inflight := make(chan struct{}, 2*workers)
+
+	var wg sync.WaitGroup
+	for _, dec := range decoders {
+		wg.Go(func() {
+			lastFrame := int64(-1)
+			for {
+				var job pipelineJob
+				select {
+				case j, ok := <-jobs:
+					if !ok {
+						return
+					}
+					job = j
+				case <-runCtx.Done():
+					return
+				}
+
+				seg, err := decodeSegment(runCtx, dec, job, lastFrame)
+				lastFrame = job.first + int64(len(job.frames)) - 1
+				select {
+				case results <- pipelineResult{index: job.index, seg: seg, err: err}:
+				case <-runCtx.Done():
+					return
+				}
+			}
+		})
+	}
+
+	demuxErr := make(chan error, 1)
+	go func() {
+		defer close(jobs)
+		demuxErr <- demuxSegments(runCtx, next, segmentFrames, jobs, inflight)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	err := collectSegments(results, inflight, emit)
+	if err != nil {
+		cancel()
+		for range results {
+			// Drain so workers can exit before decoders are closed
+		}
+		// Do not return while the demuxer may still be calling next
+		<-demuxErr
+		return err
+	}
+
+	if err := <-demuxErr; err != nil {
+		return err
+	}
+	// Workers stop early on cancellation, dropping segments silently
+	return ctx.Err()
+}
+
+// DecodeADTS demuxes an ADTS stream from r and decodes it with [Pipeline.Decode].
+func (p Pipeline) DecodeADTS(ctx context.Context, r io.Reader, emit func(DecodedSegment) error) error {
+	ar := newADTSReader(r, newOptions(p.Options))
+
+	header, err := ar.readHeader()
+	if err != nil {
+		return err
+	}
+	config, err := ar.configFromHeader(header)
+	if err != nil {
+		return err
+	}
+	first, err := ar.readPayload(header)
+	if err != nil {
+		return err
+	}
+
+	next := func() ([]byte, error) {
+		if first != nil {
+			frame := first
+			first = nil
+			return ar.opts.transform(frame)
+		}
+		frame, err := ar.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		return ar.opts.transform(frame.data)
+	}
+
+	return p.Decode(ctx, config, next, emit)
+}
+
+// demuxSegments reads frames into segments and sends them as jobs.
+func demuxSegments(
+	ctx context.Context,
+	next func() ([]byte, error),
+	segmentFrames int,
+	jobs chan<- pipelineJob,
+	inflight chan struct{},
+) error {
+	var (
+		preroll []byte
+		first   int64
+	)
+	for index := 0; ; index++ {
+		frames := make([][]byte, 0, segmentFrames)
+		eof := false
+		for len(frames) < segmentFrames {
+			frame, err := next()
+			if errors.Is(err, io.EOF) {
+				eof = true
+				break
+			}
+			if err != nil {
+				return err
+			}
+			frames = append(frames, frame)
+		}
+		if len(frames) == 0 {
+			return nil
+		}
+
+		select {
+		case inflight <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		job := pipelineJob{index: index, first: first, preroll: preroll, frames: frames}
+		select {
+		case jobs <- job:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		preroll = frames[len(frames)-1]
+		first += int64(len(frames))
+		if eof {
+			return nil
+		}
+	}
+}
+
+// decodeSegment decodes one job. lastFrame is the index of the last frame the
+// decoder processed; priming is skipped when the job directly follows it.
+func decodeSegment(ctx context.Context, dec *Decoder, job pipelineJob, lastFrame int64) (DecodedSegment, error) {
+	seg := DecodedSegment{
+		FirstFrame: job.first,
+		Frames:     len(job.frames),
+		SampleRate: dec.SampleRate(),
+		Channels:   dec.Channels(),
+	}
+
+	if job.preroll != nil && lastFrame != job.first-1 {
+		if _, err := dec.Decode(ctx, job.preroll); err != nil {
+			return seg, err
+		}
+	}
+
+	for _, frame := range job.frames {
+		pcm, err := dec.Decode(ctx, frame)
+		if err != nil {
+			return seg, err
+		}
+		seg.PCM = append(seg.PCM, pcm...)
+	}
+
+	return seg, nil
+}
+
+// collectSegments emits results in order, releasing an inflight slot per segment.
+func collectSegments(results <-chan pipelineResult, inflight <-chan struct{}, emit func(DecodedSegment) error) error {
+	pending := make(map[int]DecodedSegment)
+	nextIndex := 0
+
+	for res := range results {
+		if res.err != nil {
+			return res.err
+		}
+		pending[res.index] = res.seg
+
+		for {
+			seg, ok := pending[nextIndex]
+			if !ok {
+				break
+			}
+			delete(pending, nextIndex)
+			nextIndex++
+			<-inflight
+
+			if err := emit(seg); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
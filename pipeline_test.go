@@ -0,0 +1,142 @@
+package faad2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPipelineDecodeADTS(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(100)
+
+	// Sequential reference
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	pcm := make([]int16, 4096)
+	expected := 0
+	for {
+		n, err := reader.Read(ctx, pcm)
+		expected += n
+		if err != nil {
+			break
+		}
+	}
+
+	p := Pipeline{Workers: 3, SegmentFrames: 7}
+	total := 0
+	nextFrame := int64(0)
+	err = p.DecodeADTS(ctx, bytes.NewReader(stream), func(seg DecodedSegment) error {
+		if seg.FirstFrame != nextFrame {
+			t.Errorf("segment out of order: expected first frame %d, got %d", nextFrame, seg.FirstFrame)
+		}
+		if seg.SampleRate != 44100 || seg.Channels != 2 {
+			t.Errorf("unexpected format: %d Hz, %d channels", seg.SampleRate, seg.Channels)
+		}
+		nextFrame += int64(seg.Frames)
+		total += len(seg.PCM)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DecodeADTS failed: %v", err)
+	}
+
+	if nextFrame != 100 {
+		t.Errorf("expected 100 frames, got %d", nextFrame)
+	}
+	if total != expected {
+		t.Errorf("expected %d samples as sequential decode, got %d", expected, total)
+	}
+}
+
+func TestPipelineEmitError(t *testing.T) {
+	ctx := context.Background()
+	errStop := errors.New("stop")
+
+	p := Pipeline{Workers: 2, SegmentFrames: 4}
+	calls := 0
+	err := p.DecodeADTS(ctx, bytes.NewReader(buildTestADTSStream(100)), func(DecodedSegment) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("expected emit error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected emit to stop after first error, got %d calls", calls)
+	}
+}
+
+func TestPipelineEmitErrorWaitsForNext(t *testing.T) {
+	ctx := context.Background()
+	errStop := errors.New("stop")
+
+	var returned, late atomic.Bool
+	next := func() ([]byte, error) {
+		time.Sleep(time.Millisecond)
+		if returned.Load() {
+			late.Store(true)
+		}
+		return silentStereoFrame, nil
+	}
+
+	p := Pipeline{Workers: 2, SegmentFrames: 4}
+	err := p.Decode(ctx, []byte{0x12, 0x10}, next, func(DecodedSegment) error {
+		return errStop
+	})
+	returned.Store(true)
+	if !errors.Is(err, errStop) {
+		t.Errorf("expected emit error, got %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if late.Load() {
+		t.Error("expected next not to be called after Decode returned")
+	}
+}
+
+func TestPipelineCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := Pipeline{Workers: 2, SegmentFrames: 4}
+	frames := 0
+	err := p.DecodeADTS(ctx, bytes.NewReader(buildTestADTSStream(100)), func(seg DecodedSegment) error {
+		frames += seg.Frames
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if frames >= 100 {
+		t.Errorf("expected decode to stop early, got %d frames", frames)
+	}
+}
+
+func TestDedicatedModuleDecoders(t *testing.T) {
+	ctx := context.Background()
+
+	dec1, err := NewDecoder(ctx, WithDedicatedModule())
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec1.Close(ctx)
+
+	dec2, err := NewDecoder(ctx, WithDedicatedModule())
+	if err != nil {
+		t.Fatalf("NewDecoder failed: %v", err)
+	}
+	defer dec2.Close(ctx)
+
+	if dec1.wctx == dec2.wctx {
+		t.Error("expected decoders to use separate module instances")
+	}
+}
@@ -0,0 +1,70 @@
+package faad2
+
+import "math"
+
+// maxPlaybackRate is the highest playback rate accepted by SetPlaybackRate.
+const maxPlaybackRate = 4.0
+
+// rateResampler changes playback speed by linearly resampling interleaved PCM.
+//
+// Pitch is not preserved: playing at 1.5x raises pitch accordingly. State is
+// carried across calls so that frame boundaries do not produce clicks.
+type rateResampler struct {
+	rate     float64
+	channels int
+
+	// pos is the read position of the next output sample, in input frames,
+	// relative to the start of the next input chunk. It may be negative,
+	// in which case interpolation uses the last frame of the previous chunk.
+	pos  float64
+	last []int16
+}
+
+func newRateResampler(rate float64, channels int) *rateResampler {
+	return &rateResampler{
+		rate:     rate,
+		channels: channels,
+	}
+}
+
+// process resamples one chunk of interleaved PCM and returns the output.
+func (r *rateResampler) process(in []int16) []int16 {
+	ch := r.channels
+	n := len(in) / ch
+	if n == 0 {
+		return nil
+	}
+
+	// frame returns input sample at frame index i (-1 refers to the last
+	// frame of the previous chunk) for channel c.
+	frame := func(i, c int) float64 {
+		if i < 0 {
+			if r.last == nil {
+				return float64(in[c])
+			}
+			return float64(r.last[c])
+		}
+		return float64(in[i*ch+c])
+	}
+
+	out := make([]int16, 0, int(float64(n)/r.rate+1)*ch)
+	t := r.pos
+	for t < float64(n-1) {
+		i := int(math.Floor(t))
+		frac := t - float64(i)
+		for c := range ch {
+			a := frame(i, c)
+			b := frame(i+1, c)
+			out = append(out, int16(math.Round(a+(b-a)*frac)))
+		}
+		t += r.rate
+	}
+
+	r.pos = t - float64(n)
+	if r.last == nil {
+		r.last = make([]int16, ch)
+	}
+	copy(r.last, in[(n-1)*ch:n*ch])
+
+	return out
+}
@@ -0,0 +1,196 @@
+package faad2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestRateResamplerLength(t *testing.T) {
+	tests := []struct {
+		rate     float64
+		expected int
+	}{
+		{rate: 2, expected: 512},
+		{rate: 0.5, expected: 2048},
+		{rate: 1.25, expected: 820},
+	}
+
+	for _, tt := range tests {
+		r := newRateResampler(tt.rate, 2)
+		in := make([]int16, 1024*2)
+		total := 0
+		for range 4 {
+			total += len(r.process(in))
+		}
+		// Output frames per 4 chunks of 1024 frames should be ~4096/rate
+		got := total / 2 / 4
+		if got < tt.expected-2 || got > tt.expected+2 {
+			t.Errorf("rate %v: expected ~%d frames per chunk, got %d", tt.rate, tt.expected, got)
+		}
+	}
+}
+
+func TestRateResamplerInterpolation(t *testing.T) {
+	r := newRateResampler(0.5, 1)
+	out := r.process([]int16{0, 100, 200})
+
+	expected := []int16{0, 50, 100, 150}
+	if len(out) != len(expected) {
+		t.Fatalf("expected %d samples, got %d", len(expected), len(out))
+	}
+	for i := range expected {
+		if out[i] != expected[i] {
+			t.Errorf("sample %d: expected %d, got %d", i, expected[i], out[i])
+		}
+	}
+
+	// The next chunk continues from the last frame of the previous one
+	out = r.process([]int16{300, 400})
+	expected = []int16{200, 250, 300, 350}
+	if len(out) != len(expected) {
+		t.Fatalf("expected %d samples, got %d", len(expected), len(out))
+	}
+	for i := range expected {
+		if out[i] != expected[i] {
+			t.Errorf("sample %d: expected %d, got %d", i, expected[i], out[i])
+		}
+	}
+}
+
+func TestADTSSetPlaybackRate(t *testing.T) {
+	ctx := context.Background()
+
+	readAll := func(rate float64) int {
+		reader, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStream(20)))
+		if err != nil {
+			t.Fatalf("OpenADTS failed: %v", err)
+		}
+		defer reader.Close(ctx)
+
+		if err := reader.SetPlaybackRate(rate); err != nil {
+			t.Fatalf("SetPlaybackRate failed: %v", err)
+		}
+		if reader.PlaybackRate() != rate {
+			t.Errorf("expected playback rate %v, got %v", rate, reader.PlaybackRate())
+		}
+
+		pcm := make([]int16, 4096)
+		total := 0
+		for {
+			n, err := reader.Read(ctx, pcm)
+			total += n
+			if err != nil {
+				break
+			}
+		}
+		return total
+	}
+
+	normal := readAll(1)
+	fast := readAll(1.5)
+
+	if normal == 0 {
+		t.Fatal("no samples decoded")
+	}
+	ratio := float64(normal) / float64(fast)
+	if ratio < 1.45 || ratio > 1.55 {
+		t.Errorf("expected 1.5x fewer samples at 1.5x rate, got ratio %.3f (%d vs %d)", ratio, normal, fast)
+	}
+}
+
+func TestADTSSetPlaybackRateConcatenated(t *testing.T) {
+	ctx := context.Background()
+
+	// A stereo stream followed by a three-channel one
+	stream := append(buildTestADTSStream(5), buildTestADTSStreamOf(4, 3, silentThreeChannelFrame, 5)...)
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	if err := reader.SetPlaybackRate(1.5); err != nil {
+		t.Fatalf("SetPlaybackRate failed: %v", err)
+	}
+
+	var frames []int
+	for {
+		pcm, err := reader.ReadFrame(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadFrame failed: %v", err)
+		}
+		if len(pcm) > 0 {
+			frames = append(frames, len(pcm))
+		}
+	}
+
+	if reader.Channels() != 3 {
+		t.Errorf("expected 3 channels after the switch, got %d", reader.Channels())
+	}
+	if len(frames) != 8 {
+		t.Fatalf("expected 8 frames of output, got %d", len(frames))
+	}
+	// Each part primes its own decoder, so each yields 4 frames
+	for i, n := range frames[4:] {
+		if n%3 != 0 {
+			t.Errorf("frame %d: %d samples is not a whole number of 3-channel frames", i+4, n)
+		}
+	}
+	total := 0
+	for _, n := range frames[4:] {
+		total += n
+	}
+	if want := 4 * 1024 * 3 * 2 / 3; total < want-3*3 || total > want+3*3 {
+		t.Errorf("expected about %d samples from the second part at 1.5x, got %d", want, total)
+	}
+}
+
+func TestADTSPlaybackRateConcurrent(t *testing.T) {
+	ctx := context.Background()
+	reader, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStream(5)))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			_ = reader.PlaybackRate()
+		}
+	}()
+	for i := range 100 {
+		if err := reader.SetPlaybackRate(1 + float64(i%2)/2); err != nil {
+			t.Fatalf("SetPlaybackRate failed: %v", err)
+		}
+	}
+	<-done
+
+	if reader.PlaybackRate() != 1.5 {
+		t.Errorf("expected playback rate 1.5, got %v", reader.PlaybackRate())
+	}
+}
+
+func TestADTSSetPlaybackRateInvalid(t *testing.T) {
+	ctx := context.Background()
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStream(2)))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	for _, rate := range []float64{0, -1, 5} {
+		if err := reader.SetPlaybackRate(rate); !errors.Is(err, ErrInvalidPlaybackRate) {
+			t.Errorf("rate %v: expected ErrInvalidPlaybackRate, got %v", rate, err)
+		}
+	}
+}
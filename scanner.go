@@ -0,0 +1,98 @@
+package faad2
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync"
+)
+
+// Scanner opens many ADTS streams one after another, for library scans that
+// read metadata or measure loudness across thousands of files.
+//
+// Opening a stream with [OpenADTS] allocates a read buffer and, with
+// [WithDedicatedModule], instantiates a WASM module. A Scanner instead keeps
+// one WASM module of its own and one read buffer, and reuses them for every
+// stream it opens; only the small FAAD2 decoder state is created per stream.
+//
+// Only one stream is open at a time: opening a stream closes the reader
+// returned for the previous one. A Scanner is safe for concurrent use, but
+// scanning in parallel requires one Scanner per goroutine.
+type Scanner struct {
+	mu     sync.Mutex
+	opts   []Option
+	wctx   *wasmContext
+	reader *ADTSReader
+	closed bool
+}
+
+// NewScanner creates a Scanner opening streams with the given options. Call
+// [Scanner.Close] when done to release the WASM module.
+func NewScanner(ctx context.Context, opts ...Option) (*Scanner, error) {
+	o := newOptions(opts)
+
+	wctx, err := newDedicatedWasmContext(ctx, o.decodeTimeout > 0)
+	if err != nil {
+		return nil, err
+	}
+
+	size := defaultADTSBufferSize
+	if o.bufferSizeSet {
+		size = o.bufferSize
+	}
+	var buffer *bufio.Reader
+	if size > 0 {
+		buffer = bufio.NewReaderSize(nil, size)
+	}
+
+	reuse := func(o *options) {
+		o.module = wctx
+		o.buffer = buffer
+	}
+	return &Scanner{
+		opts: append(append([]Option(nil), opts...), reuse),
+		wctx: wctx,
+	}, nil
+}
+
+// OpenADTS opens an ADTS stream like [OpenADTS], reusing the Scanner's
+// resources. The reader returned by the previous call is closed first.
+//
+// Returns [ErrDecoderClosed] after [Scanner.Close].
+func (sc *Scanner) OpenADTS(ctx context.Context, r io.Reader) (*ADTSReader, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.closed {
+		return nil, ErrDecoderClosed
+	}
+	if sc.reader != nil {
+		_ = sc.reader.Close(ctx)
+		sc.reader = nil
+	}
+
+	ar, err := OpenADTS(ctx, r, sc.opts...)
+	if err != nil {
+		return nil, err
+	}
+	sc.reader = ar
+	return ar, nil
+}
+
+// Close closes the reader returned by the last [Scanner.OpenADTS] call and
+// releases the WASM module. It is safe to call Close multiple times.
+func (sc *Scanner) Close(ctx context.Context) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.closed {
+		return nil
+	}
+	sc.closed = true
+
+	if sc.reader != nil {
+		_ = sc.reader.Close(ctx)
+		sc.reader = nil
+	}
+	return sc.wctx.close(ctx)
+}
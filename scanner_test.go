@@ -0,0 +1,51 @@
+package faad2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestScanner(t *testing.T) {
+	ctx := context.Background()
+
+	sc, err := NewScanner(ctx)
+	if err != nil {
+		t.Fatalf("NewScanner failed: %v", err)
+	}
+	defer sc.Close(ctx)
+
+	var previous *ADTSReader
+	for i := range 20 {
+		// A plain io.Reader goes through the reused read buffer
+		src := &countingReader{r: bytes.NewReader(buildTestADTSStream(5))}
+		reader, err := sc.OpenADTS(ctx, src)
+		if err != nil {
+			t.Fatalf("OpenADTS %d failed: %v", i, err)
+		}
+		if reader.decoder.wctx != sc.wctx {
+			t.Fatal("expected the scanner module to be reused")
+		}
+		if total := readAllSamples(t, reader.Read, 4096); total != 4*2048 {
+			t.Errorf("stream %d: expected %d samples, got %d", i, 4*2048, total)
+		}
+
+		if previous != nil {
+			if _, err := previous.Read(ctx, make([]int16, 16)); !errors.Is(err, ErrDecoderClosed) {
+				t.Errorf("expected previous reader to be closed, got %v", err)
+			}
+		}
+		previous = reader
+	}
+
+	if err := sc.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := sc.Close(ctx); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+	if _, err := sc.OpenADTS(ctx, bytes.NewReader(buildTestADTSStream(5))); !errors.Is(err, ErrDecoderClosed) {
+		t.Errorf("expected ErrDecoderClosed after Close, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+// Package spectrum computes short-time FFT magnitudes from decoded PCM, for
+// visualizers and simple content analysis.
+//
+//	analyzer := spectrum.New(int(reader.Channels()), spectrum.WithSize(2048))
+//	for {
+//	    n, err := reader.Read(ctx, pcm)
+//	    for _, frame := range analyzer.Process(pcm[:n]) {
+//	        // frame[k] is the magnitude at analyzer.BinFrequency(k, rate)
+//	    }
+//	    if err != nil {
+//	        break
+//	    }
+//	}
+//
+// Channels are mixed down to mono before analysis, and each window is
+// weighted with a Hann window.
+package spectrum
+
+import (
+	"math"
+	"math/bits"
+)
+
+const (
+	// DefaultSize is the default FFT window size in samples per channel.
+	DefaultSize = 2048
+
+	// fullScale is the magnitude of a full-scale 16-bit sample.
+	fullScale = 32768
+)
+
+// Analyzer splits interleaved PCM into overlapping windows and returns the
+// magnitude spectrum of each.
+//
+// An Analyzer is not safe for concurrent use.
+type Analyzer struct {
+	size     int
+	hop      int
+	channels int
+
+	window   []float64
+	scale    float64
+	samples  []float64 // mono samples not yet consumed by a window
+	re, im   []float64
+	bitOrder []int
+}
+
+// Option configures an [Analyzer].
+type Option func(*Analyzer)
+
+// WithSize sets the window size in samples per channel. It must be a power of
+// two of at least 2; other values are ignored.
+func WithSize(size int) Option {
+	return func(a *Analyzer) {
+		if size >= 2 && size&(size-1) == 0 {
+			a.size = size
+		}
+	}
+}
+
+// WithHop sets the number of samples per channel between the starts of
+// consecutive windows. Defaults to half the window size; values outside
+// (0, size] are ignored.
+func WithHop(hop int) Option {
+	return func(a *Analyzer) {
+		a.hop = hop
+	}
+}
+
+// New creates an analyzer for PCM with the given number of interleaved
+// channels.
+func New(channels int, opts ...Option) *Analyzer {
+	a := &Analyzer{
+		size:     DefaultSize,
+		channels: max(channels, 1),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.hop <= 0 || a.hop > a.size {
+		a.hop = a.size / 2
+	}
+
+	a.window = make([]float64, a.size)
+	sum := 0.0
+	for i := range a.window {
+		a.window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(a.size))
+		sum += a.window[i]
+	}
+	// A full-scale sine centered on a bin has magnitude 1.
+	a.scale = 2 / (sum * fullScale)
+
+	a.re = make([]float64, a.size)
+	a.im = make([]float64, a.size)
+	a.bitOrder = make([]int, a.size)
+	shift := bits.UintSize - bits.TrailingZeros(uint(a.size))
+	for i := range a.bitOrder {
+		a.bitOrder[i] = int(bits.Reverse(uint(i)) >> shift)
+	}
+
+	return a
+}
+
+// Size returns the window size in samples per channel.
+func (a *Analyzer) Size() int {
+	return a.size
+}
+
+// Bins returns the number of magnitudes per frame, size/2 + 1.
+func (a *Analyzer) Bins() int {
+	return a.size/2 + 1
+}
+
+// BinFrequency returns the center frequency in Hz of bin k.
+func (a *Analyzer) BinFrequency(k int, sampleRate uint32) float64 {
+	return float64(k) * float64(sampleRate) / float64(a.size)
+}
+
+// Process adds interleaved samples and returns the magnitude spectrum of
+// every window completed by them, in order. Samples not yet covering a full
+// window are kept for the next call.
+//
+// Each frame has [Analyzer.Bins] magnitudes, from DC to the Nyquist
+// frequency, scaled so that a full-scale sine has a magnitude of about 1.
+func (a *Analyzer) Process(pcm []int16) [][]float64 {
+	for i := 0; i+a.channels <= len(pcm); i += a.channels {
+		sum := 0
+		for _, s := range pcm[i : i+a.channels] {
+			sum += int(s)
+		}
+		a.samples = append(a.samples, float64(sum)/float64(a.channels))
+	}
+
+	var frames [][]float64
+	consumed := 0
+	for len(a.samples)-consumed >= a.size {
+		frames = append(frames, a.analyze(a.samples[consumed:consumed+a.size]))
+		consumed += a.hop
+	}
+	a.samples = append(a.samples[:0], a.samples[consumed:]...)
+
+	return frames
+}
+
+// Reset discards buffered samples, for example after seeking.
+func (a *Analyzer) Reset() {
+	a.samples = a.samples[:0]
+}
+
+// analyze returns the magnitude spectrum of one window of samples.
+func (a *Analyzer) analyze(samples []float64) []float64 {
+	for i, s := range samples {
+		j := a.bitOrder[i]
+		a.re[j] = s * a.window[i]
+		a.im[j] = 0
+	}
+	fft(a.re, a.im)
+
+	mags := make([]float64, a.Bins())
+	for k := range mags {
+		mags[k] = math.Hypot(a.re[k], a.im[k]) * a.scale
+	}
+	mags[0] /= 2
+	mags[len(mags)-1] /= 2
+	return mags
+}
+
+// fft performs an in-place radix-2 FFT on input already in bit-reversed order.
+func fft(re, im []float64) {
+	n := len(re)
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		step := -2 * math.Pi / float64(size)
+		for start := 0; start < n; start += size {
+			for k := range half {
+				wr, wi := math.Cos(step*float64(k)), math.Sin(step*float64(k))
+				i, j := start+k, start+k+half
+				tr := wr*re[j] - wi*im[j]
+				ti := wr*im[j] + wi*re[j]
+				re[j], im[j] = re[i]-tr, im[i]-ti
+				re[i], im[i] = re[i]+tr, im[i]+ti
+			}
+		}
+	}
+}
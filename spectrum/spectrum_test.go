@@ -0,0 +1,77 @@
+package spectrum
+
+import (
+	"math"
+	"testing"
+)
+
+func sine(freq float64, sampleRate, n, channels int, amplitude float64) []int16 {
+	pcm := make([]int16, n*channels)
+	for i := range n {
+		v := int16(amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)))
+		for c := range channels {
+			pcm[i*channels+c] = v
+		}
+	}
+	return pcm
+}
+
+func TestAnalyzerPeak(t *testing.T) {
+	const (
+		size       = 1024
+		sampleRate = 48000
+		bin        = 64
+	)
+	a := New(2, WithSize(size))
+	freq := a.BinFrequency(bin, sampleRate)
+
+	frames := a.Process(sine(freq, sampleRate, size, 2, 16384))
+	if len(frames) != 1 {
+		t.Fatalf("expected 1 frame, got %d", len(frames))
+	}
+	mags := frames[0]
+	if len(mags) != a.Bins() {
+		t.Fatalf("expected %d bins, got %d", a.Bins(), len(mags))
+	}
+
+	peak := 0
+	for k := range mags {
+		if mags[k] > mags[peak] {
+			peak = k
+		}
+	}
+	if peak != bin {
+		t.Errorf("expected peak at bin %d, got %d", bin, peak)
+	}
+	if math.Abs(mags[bin]-0.5) > 0.01 {
+		t.Errorf("expected magnitude 0.5 for half-scale sine, got %v", mags[bin])
+	}
+	if mags[bin+10] > 1e-3 {
+		t.Errorf("expected leakage far from the peak to be small, got %v", mags[bin+10])
+	}
+}
+
+func TestAnalyzerHop(t *testing.T) {
+	a := New(1, WithSize(1024), WithHop(512))
+	pcm := make([]int16, 4096)
+
+	// Feed in uneven chunks; frame count must not depend on chunking
+	frames := 0
+	for start := 0; start < len(pcm); start += 700 {
+		frames += len(a.Process(pcm[start:min(start+700, len(pcm))]))
+	}
+
+	if want := (4096-1024)/512 + 1; frames != want {
+		t.Errorf("expected %d frames, got %d", want, frames)
+	}
+}
+
+func TestAnalyzerInvalidOptions(t *testing.T) {
+	a := New(2, WithSize(1000), WithHop(-1))
+	if a.Size() != DefaultSize {
+		t.Errorf("expected default size for non-power-of-two, got %d", a.Size())
+	}
+	if a.hop != DefaultSize/2 {
+		t.Errorf("expected default hop, got %d", a.hop)
+	}
+}
@@ -0,0 +1,125 @@
+package faad2
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrInvalidState is returned when restoring a [State] with negative values.
+var ErrInvalidState = errors.New("faad2: invalid reader state")
+
+// State is a playback position saved with [ADTSReader.SaveState], from which
+// [RestoreADTS] resumes decoding at the exact sample, for players that
+// persist positions across process restarts. Its fields are exported so it
+// can be stored with any encoding.
+type State struct {
+	// Offset is the byte offset in the source of the frame decoding resumes
+	// from. Offsets count from the start of a seekable source, or from where
+	// the reader started reading otherwise.
+	Offset int64
+
+	// Skip is the number of samples decoded from Offset onwards that had
+	// already been returned.
+	Skip int64
+
+	// Samples is the number of interleaved samples returned before the
+	// state was saved.
+	Samples int64
+}
+
+// SaveState returns the position of the next sample Read would return.
+//
+// Decoding resumes one frame ahead of that sample, since an AAC frame
+// overlaps its predecessor, and Skip counts the samples returned since.
+//
+// Returns [ErrDecoderClosed] after [ADTSReader.Close].
+func (ar *ADTSReader) SaveState() (State, error) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	if ar.closed {
+		return State{}, ErrDecoderClosed
+	}
+	if ar.decoder == nil {
+		return State{}, ErrNotInitialized
+	}
+
+	// The frame holding the next sample, preceded by the frame whose
+	// decoding primes it. The first frame of the stream primes itself.
+	i := len(ar.spans) - 1
+	for i > 0 && ar.spans[i].start > ar.samplesOut {
+		i--
+	}
+	if i < 0 {
+		// Priming deferred until the first Read
+		return State{Offset: ar.firstOffset}, nil
+	}
+	offset := ar.spans[i].offset
+	if i > 0 {
+		offset = ar.spans[i-1].offset
+	}
+	return State{
+		Offset:  offset,
+		Skip:    ar.samplesOut - ar.spans[i].start,
+		Samples: ar.samplesOut,
+	}, nil
+}
+
+// RestoreADTS opens the ADTS stream r at a position saved with
+// [ADTSReader.SaveState], so that the first Read returns the sample that
+// followed the save. Give the options the saved reader was opened with; the
+// stream times of [ADTSReader.ReadTimed] continue from the saved position.
+// [WithStartOffset], [WithEndOffset] and [WithPeakNormalization] apply from
+// the restored position rather than from the start of the stream.
+//
+// Returns [ErrInvalidState] for a state with negative values, the error from
+// seeking r, or any error from [OpenADTS] or from decoding up to the
+// position. A position at the end of the stream returns a reader whose first
+// Read returns io.EOF.
+func RestoreADTS(ctx context.Context, r io.ReadSeeker, state State, opts ...Option) (*ADTSReader, error) {
+	if state.Offset < 0 || state.Skip < 0 || state.Samples < state.Skip {
+		return nil, ErrInvalidState
+	}
+	if _, err := r.Seek(state.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	ar, err := OpenADTS(ctx, r, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ar.resume(ctx, state); err != nil {
+		ar.Close(ctx)
+		return nil, err
+	}
+	return ar, nil
+}
+
+// resume moves a stream freshly opened at state.Offset to the saved sample.
+func (s *pcmStream) resume(ctx context.Context, state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Count output from the saved position rather than from the reopening
+	base := state.Samples - state.Skip
+	for i := range s.spans {
+		s.spans[i].start += base
+	}
+	s.produced += base
+	s.samplesOut += base
+
+	discard := make([]int16, min(state.Skip, 8192))
+	for skip := state.Skip; skip > 0; {
+		n, err := s.readLocked(ctx, discard[:min(skip, int64(len(discard)))])
+		skip -= int64(n)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package faad2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// readN reads exactly n samples from r.
+func readN(t *testing.T, r *ADTSReader, n int) {
+	t.Helper()
+	pcm := make([]int16, 1000)
+	for n > 0 {
+		got, err := r.Read(context.Background(), pcm[:min(n, len(pcm))])
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		n -= got
+	}
+}
+
+func TestSaveRestoreState(t *testing.T) {
+	ctx := context.Background()
+
+	const frames = 10
+	stream := buildTestADTSStream(frames)
+	frameLen := int64(7 + len(silentStereoFrame))
+	total := (frames - 1) * 2048 // the first frame primes the decoder
+
+	tests := []struct {
+		name string
+		cut  int
+		opts []Option
+	}{
+		{"start", 0, nil},
+		{"mid frame", 100, nil},
+		{"frame boundary", 2048, nil},
+		{"later frame", 5000, nil},
+		{"end", total, nil},
+		{"look-ahead", 5000, []Option{WithLookAhead()}},
+		{"deferred priming", 0, []Option{WithDeferredPriming()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := OpenADTS(ctx, bytes.NewReader(stream), tt.opts...)
+			if err != nil {
+				t.Fatalf("OpenADTS failed: %v", err)
+			}
+			readN(t, reader, tt.cut)
+			state, err := reader.SaveState()
+			if err != nil {
+				t.Fatalf("SaveState failed: %v", err)
+			}
+			reader.Close(ctx)
+
+			if state.Samples != int64(tt.cut) {
+				t.Errorf("expected %d saved samples, got %d", tt.cut, state.Samples)
+			}
+			if state.Offset%frameLen != 0 {
+				t.Errorf("offset %d is not at a frame boundary", state.Offset)
+			}
+			if state.Skip > 2*2048 {
+				t.Errorf("expected to skip at most one frame, got %d samples", state.Skip)
+			}
+
+			restored, err := RestoreADTS(ctx, bytes.NewReader(stream), state, tt.opts...)
+			if err != nil {
+				t.Fatalf("RestoreADTS failed: %v", err)
+			}
+			defer restored.Close(ctx)
+
+			read := 0
+			if tt.cut < total {
+				n, start, err := restored.ReadTimed(ctx, make([]int16, 2))
+				if err != nil {
+					t.Fatalf("ReadTimed failed: %v", err)
+				}
+				want := time.Duration(tt.cut/2) * time.Second / 44100
+				if start != want {
+					t.Errorf("expected resumed stream time %v, got %v", want, start)
+				}
+				read = n
+			}
+			if rest := read + readAllSamples(t, restored.Read, 4096); rest != total-tt.cut {
+				t.Errorf("expected %d samples after restoring, got %d", total-tt.cut, rest)
+			}
+		})
+	}
+}
+
+func TestSaveStateTwice(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(10)
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+	readN(t, reader, 3000)
+	state, err := reader.SaveState()
+	if err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+
+	// Saving right after restoring returns the same position
+	restored, err := RestoreADTS(ctx, bytes.NewReader(stream), state)
+	if err != nil {
+		t.Fatalf("RestoreADTS failed: %v", err)
+	}
+	defer restored.Close(ctx)
+	again, err := restored.SaveState()
+	if err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+	if again != state {
+		t.Errorf("expected %+v, got %+v", state, again)
+	}
+}
+
+func TestRestoreADTSInvalidState(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(5)
+
+	for _, state := range []State{{Offset: -1}, {Skip: -1}, {Skip: 10, Samples: 5}} {
+		if _, err := RestoreADTS(ctx, bytes.NewReader(stream), state); !errors.Is(err, ErrInvalidState) {
+			t.Errorf("%+v: expected ErrInvalidState, got %v", state, err)
+		}
+	}
+
+	// An offset past the end finds no frame
+	_, err := RestoreADTS(ctx, bytes.NewReader(stream), State{Offset: int64(len(stream))})
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF past the end, got %v", err)
+	}
+}
+
+func TestSaveStateClosed(t *testing.T) {
+	ctx := context.Background()
+	reader, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStream(5)))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	reader.Close(ctx)
+
+	if _, err := reader.SaveState(); !errors.Is(err, ErrDecoderClosed) {
+		t.Errorf("expected ErrDecoderClosed, got %v", err)
+	}
+}
@@ -0,0 +1,892 @@
+package faad2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// pcmStream decodes AAC frames supplied by a container reader and serves the
+// resulting PCM through an internal buffer, so callers can read with any
+// buffer size. It is embedded by the container readers.
+type pcmStream struct {
+	// mu serializes reads with close, so that a reader can be closed from
+	// another goroutine while a read is in progress.
+	mu     sync.Mutex
+	closed bool
+
+	decoder *Decoder
+	opts    options
+
+	// nextFrame returns the next raw AAC frame from the container. It may
+	// run on the look-ahead goroutine, so it must not change state read
+	// outside of it; the progress it makes is returned with the frame.
+	nextFrame func() (containerFrame, error)
+
+	// Completed parts of a stream whose format changed, and the start of the
+	// current part: its first output sample, stream time, frames read before
+	// it and their duration
+	parts        []streamPart
+	partStart    int64
+	partTime     time.Duration
+	partFrames   int64
+	partsDecoded time.Duration
+
+	// Recently decoded frames and the output sample index where each starts,
+	// and the number of samples produced so far, for SaveState
+	spans    []frameSpan
+	produced int64
+
+	// deadliner is the underlying reader if it supports read deadlines, used
+	// by WithReadTimeout. stalled is set once a read without deadline support
+	// has timed out and been abandoned; every later read then fails without
+	// touching the container parser, which the abandoned read still owns.
+	deadliner readDeadliner
+	stalled   bool
+
+	// PCM buffer for partial reads
+	pcmBuffer []int16
+	pcmOffset int
+
+	// Interleaved scratch buffer for planar reads
+	planarBuf []int16
+
+	// Frame tracking
+	framesRead    int64
+	framesSkipped int64
+	bytesRead     int64
+
+	// Container bytes and seconds of audio parsed so far, for duration
+	// estimates, and the container timestamp of the last frame
+	bytesParsed   int64
+	secondsParsed float64
+	timestamp     uint32
+
+	// Codec configuration the decoder was initialized with
+	asc audioSpecificConfig
+
+	// Number of samples produced by the last decoded frame, and a copy of
+	// them kept for WithRepeatConcealment
+	frameSamples int
+	lastFrame    []int16
+
+	concealment Concealment
+
+	// Leading frames that produced no output while the decoder primed, and
+	// the per-channel length of the first frame that did
+	primingFrames int
+	firstFrameLen int
+
+	// Output format reported to callers. coreSampleRate is the rate signaled
+	// by the container, used to detect SBR upsampling (0 disables detection).
+	sampleRate     uint32
+	channels       uint8
+	coreSampleRate uint32
+
+	// Playback rate resampling (nil at normal speed)
+	resampler *rateResampler
+
+	// Gain applied for WithPeakNormalization (0 when disabled)
+	gain float64
+
+	// Frame being decoded ahead by a helper goroutine (look-ahead mode)
+	pending chan decodedFrame
+
+	// End of stream reached; every later read returns io.EOF
+	eof bool
+
+	// position is the stream time of the next decoded sample, tracked for
+	// WithStartOffset and WithEndOffset.
+	position time.Duration
+
+	// Interleaved samples returned to the caller so far
+	samplesOut int64
+
+	// Error hit after samples were already copied, returned by the next read
+	deferredErr error
+}
+
+// Concealment counts corrupt frames concealed by a reader opened with
+// [WithSkipCorruptFrames], by the method used.
+type Concealment struct {
+	// Silenced is the number of frames replaced with silence.
+	Silenced int64
+
+	// Repeated is the number of frames replaced with a copy of the previous
+	// frame, with [WithRepeatConcealment].
+	Repeated int64
+}
+
+// containerFrame is a raw AAC frame read from a container, with the parsing
+// progress it accounts for.
+type containerFrame struct {
+	data []byte
+
+	// offset is the source offset of the frame, for containers that
+	// support SaveState (-1 otherwise).
+	offset int64
+
+	// bytes and seconds are the container bytes parsed to read the frame
+	// and the duration of its audio (0 if not tracked), and timestamp its
+	// container timestamp in milliseconds.
+	bytes     int64
+	seconds   float64
+	timestamp uint32
+
+	// config is the AudioSpecificConfig of a frame that starts a stream of
+	// another format, such as the next part of concatenated ADTS files.
+	config []byte
+}
+
+// decodedFrame is the result of reading and decoding one frame. A frame
+// starting a stream of another format is returned undecoded. The frame is
+// zero, with offset -1, if reading it failed.
+type decodedFrame struct {
+	pcm   []int16
+	err   error
+	frame containerFrame
+}
+
+// streamPart is a completed part of a stream whose format changed.
+type streamPart struct {
+	start    int64 // output index of its first sample
+	time     time.Duration
+	rate     int64
+	channels int64
+}
+
+// frameSpan locates the output of a decoded frame.
+type frameSpan struct {
+	offset int64 // source offset of the frame
+	start  int64 // output index of its first sample
+}
+
+// maxFrameSpans bounds the decoded frames remembered for SaveState. Older
+// frames are only needed after unreading that much audio.
+const maxFrameSpans = 64
+
+// read fills pcm with decoded samples, decoding frames as needed.
+//
+// Errors follow io.Reader conventions strictly: a read that copied samples
+// returns a nil error, and the error is reported by the next read with n == 0.
+// Once the stream is exhausted, every read returns (0, io.EOF) without
+// touching the underlying reader.
+func (s *pcmStream) read(ctx context.Context, pcm []int16) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.readLocked(ctx, pcm)
+}
+
+// readTimed is read, also returning the stream time of the first sample read.
+func (s *pcmStream) readTimed(ctx context.Context, pcm []int16) (int, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Read first: the output rate is only known once a frame is decoded
+	n, err := s.readLocked(ctx, pcm)
+	if n == 0 {
+		return 0, 0, err
+	}
+	return n, s.outputTime(s.samplesOut - int64(n)), err
+}
+
+// outputTime returns the stream time of the sample at the given interleaved
+// index of the output. The caller must hold s.mu.
+func (s *pcmStream) outputTime(sample int64) time.Duration {
+	start, at := s.partStart, s.partTime
+	rate, channels := s.outputFormat()
+	for i := len(s.parts) - 1; i >= 0 && sample < start; i-- {
+		part := s.parts[i]
+		start, at, rate, channels = part.start, part.time, part.rate, part.channels
+	}
+	if rate == 0 || channels == 0 {
+		return s.opts.startOffset + at
+	}
+	return s.opts.startOffset + at + samplesDuration((sample-start)/channels, rate)
+}
+
+// samplesDuration returns the duration of n samples per channel at rate,
+// without the overflow of n*time.Second on streams longer than a day.
+func samplesDuration(n, rate int64) time.Duration {
+	return time.Duration(n/rate)*time.Second + time.Duration(n%rate)*time.Second/time.Duration(rate)
+}
+
+// outputFormat returns the sample rate and interleaved channel count of the
+// decoded output.
+func (s *pcmStream) outputFormat() (rate, channels int64) {
+	rate = int64(s.sampleRate)
+	if rate == 0 {
+		rate = int64(s.decoder.SampleRate())
+	}
+	channels = int64(s.decoder.Channels())
+	if s.opts.extractChannel {
+		channels = 1
+	}
+	return rate, channels
+}
+
+// readLocked implements read. The caller must hold s.mu.
+func (s *pcmStream) readLocked(ctx context.Context, pcm []int16) (int, error) {
+	if s.closed {
+		return 0, ErrDecoderClosed
+	}
+	if s.decoder == nil {
+		return 0, ErrNotInitialized
+	}
+
+	if len(pcm) == 0 {
+		return 0, nil
+	}
+
+	if err := s.deferredErr; err != nil && s.pcmOffset >= len(s.pcmBuffer) {
+		s.deferredErr = nil
+		return 0, err
+	}
+
+	totalRead := 0
+
+	for totalRead < len(pcm) {
+		// First, drain any buffered samples
+		if s.pcmOffset < len(s.pcmBuffer) {
+			n := copy(pcm[totalRead:], s.pcmBuffer[s.pcmOffset:])
+			s.pcmOffset += n
+			totalRead += n
+			continue
+		}
+
+		if s.eof {
+			break
+		}
+
+		// Read and decode next frame
+		samples, err := s.decodeSamples(ctx)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if totalRead > 0 {
+				s.deferredErr = err
+				s.samplesOut += int64(totalRead)
+				return totalRead, nil
+			}
+			return 0, err
+		}
+
+		if len(samples) == 0 {
+			continue
+		}
+
+		// Copy to output or buffer
+		n := copy(pcm[totalRead:], samples)
+		totalRead += n
+
+		if n < len(samples) {
+			// Buffer remaining samples
+			s.pcmBuffer = samples
+			s.pcmOffset = n
+		} else {
+			s.pcmBuffer = nil
+			s.pcmOffset = 0
+		}
+	}
+
+	s.samplesOut += int64(totalRead)
+	if totalRead == 0 && s.eof {
+		return 0, io.EOF
+	}
+	return totalRead, nil
+}
+
+// readPCMFrame returns the PCM of the next AAC frame, bypassing the sample
+// buffer. Samples left over from an earlier read are returned first.
+func (s *pcmStream) readPCMFrame(ctx context.Context) ([]int16, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrDecoderClosed
+	}
+	if s.decoder == nil {
+		return nil, ErrNotInitialized
+	}
+
+	if s.pcmOffset < len(s.pcmBuffer) {
+		rest := s.pcmBuffer[s.pcmOffset:]
+		s.pcmBuffer = nil
+		s.pcmOffset = 0
+		s.samplesOut += int64(len(rest))
+		return rest, nil
+	}
+	if err := s.deferredErr; err != nil {
+		s.deferredErr = nil
+		return nil, err
+	}
+	if s.eof {
+		return nil, io.EOF
+	}
+
+	pcm, err := s.decodeSamples(ctx)
+	s.samplesOut += int64(len(pcm))
+	return pcm, err
+}
+
+// decodeSamples decodes the next frame and applies format tracking and
+// playback rate resampling. It sets eof when the container is exhausted.
+func (s *pcmStream) decodeSamples(ctx context.Context) ([]int16, error) {
+	res := s.decodeNext(ctx)
+	samples, err := res.pcm, res.err
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			s.eof = true
+		}
+		return nil, err
+	}
+	s.framesRead++
+	s.trackPriming(len(samples))
+	s.updateFormat(len(samples))
+	samples = s.clip(samples)
+
+	if s.resampler != nil {
+		samples = s.resampler.process(samples)
+	}
+	if s.gain != 0 {
+		applyGain(samples, s.gain)
+	}
+	samples = s.selectChannel(samples)
+	s.trackSpan(res.frame.offset, len(samples))
+	return samples, nil
+}
+
+// trackSpan records where the output of a decoded frame starts. Frames of
+// unknown offset are not tracked.
+func (s *pcmStream) trackSpan(offset int64, samples int) {
+	start := s.produced
+	s.produced += int64(samples)
+	if offset < 0 {
+		return
+	}
+	s.spans = append(s.spans, frameSpan{offset: offset, start: start})
+
+	// Drop the oldest frame once its successor has been returned entirely
+	for len(s.spans) > maxFrameSpans && s.spans[2].start <= s.samplesOut {
+		s.spans = append(s.spans[:0], s.spans[1:]...)
+	}
+}
+
+// clip trims the decoded samples of a frame to the region set with
+// WithStartOffset and WithEndOffset, and sets eof once the end is reached.
+func (s *pcmStream) clip(samples []int16) []int16 {
+	start, end := s.opts.startOffset, s.opts.endOffset
+	if start == 0 && end == 0 || len(samples) == 0 {
+		return samples
+	}
+	rate := int64(s.sampleRate)
+	if rate == 0 {
+		rate = int64(s.decoder.SampleRate())
+	}
+	if rate == 0 {
+		return samples
+	}
+	channels := int64(max(s.decoder.Channels(), 1))
+
+	frames := int64(len(samples)) / channels
+	pos := s.position
+	s.position += samplesDuration(frames, rate)
+
+	// sampleAt converts a stream offset to the nearest sample index within
+	// this frame. Clamping first keeps the product from overflowing.
+	sampleAt := func(offset time.Duration) int64 {
+		d := min(max(offset-pos, 0), s.position-pos)
+		return min((int64(d)*rate+int64(time.Second)/2)/int64(time.Second), frames)
+	}
+
+	first, last := int64(0), frames
+	if start > pos {
+		first = sampleAt(start)
+	}
+	if end > 0 && s.position >= end {
+		last = max(sampleAt(end), first)
+		s.eof = true
+	}
+	return samples[first*channels : last*channels]
+}
+
+// checkChannel validates the channel selected with WithChannel against the
+// decoder output.
+func (s *pcmStream) checkChannel() error {
+	if s.opts.extractChannel && (s.opts.channel < 0 || s.opts.channel >= int(s.decoder.Channels())) {
+		return ErrInvalidChannel
+	}
+	return nil
+}
+
+// selectChannel extracts the channel selected with WithChannel from
+// interleaved samples. Without that option samples are returned unchanged.
+func (s *pcmStream) selectChannel(samples []int16) []int16 {
+	if !s.opts.extractChannel || len(samples) == 0 {
+		return samples
+	}
+	channels := int(s.decoder.Channels())
+	if channels <= 1 {
+		return samples
+	}
+
+	out := make([]int16, len(samples)/channels)
+	for i := range out {
+		out[i] = samples[i*channels+s.opts.channel]
+	}
+	return out
+}
+
+// trackPriming counts frames that decode to nothing at the start of the
+// stream. frameSamples is the raw decoded sample count of a frame.
+func (s *pcmStream) trackPriming(frameSamples int) {
+	if s.firstFrameLen > 0 {
+		return
+	}
+	if frameSamples == 0 {
+		s.primingFrames++
+		return
+	}
+	s.firstFrameLen = frameSamples / int(max(s.decoder.Channels(), 1))
+}
+
+// primingSamples returns the number of samples per channel withheld by the
+// decoder at the start of the stream.
+func (s *pcmStream) primingSamples() int {
+	frameLen := s.firstFrameLen
+	if frameLen == 0 {
+		// Not known until audio is decoded; assume no SBR upsampling
+		frameLen = coreFrameLength
+	}
+	return s.primingFrames * frameLen
+}
+
+// readFrames returns exactly frames samples per channel, reading as many
+// decoded AAC frames as needed. Surplus samples stay buffered for the next
+// call. The last block of the stream is padded with silence, and the
+// following call returns io.EOF.
+//
+// If an error interrupts a block, the samples read so far are put back, so a
+// retry after a recoverable error does not lose audio.
+func (s *pcmStream) readFrames(ctx context.Context, frames int) ([]int16, error) {
+	if frames <= 0 {
+		return nil, ErrInvalidFrameCount
+	}
+
+	channels, err := s.outputChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	block := make([]int16, frames*channels)
+	filled := 0
+	for filled < len(block) {
+		n, err := s.read(ctx, block[filled:])
+		filled += n
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, io.EOF) && filled > 0 {
+			return block, nil
+		}
+		if filled > 0 {
+			s.unread(block[:filled])
+		}
+		return nil, err
+	}
+	return block, nil
+}
+
+// readPlanar reads samples into one slice per channel and returns the number
+// of samples per channel read. len(out) must equal the output channel count.
+func (s *pcmStream) readPlanar(ctx context.Context, out [][]int16) (int, error) {
+	channels, err := s.outputChannels()
+	if err != nil {
+		return 0, err
+	}
+	if len(out) != channels {
+		return 0, ErrInvalidChannel
+	}
+
+	frames := len(out[0])
+	for _, ch := range out[1:] {
+		frames = min(frames, len(ch))
+	}
+
+	if cap(s.planarBuf) < frames*channels {
+		s.planarBuf = make([]int16, frames*channels)
+	}
+	interleaved := s.planarBuf[:frames*channels]
+
+	n, err := s.read(ctx, interleaved)
+	n /= channels
+	for i := range n {
+		for c, dst := range out {
+			dst[i] = interleaved[i*channels+c]
+		}
+	}
+	return n, err
+}
+
+// outputChannels returns the number of interleaved channels in decoded PCM.
+func (s *pcmStream) outputChannels() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, ErrDecoderClosed
+	}
+	if s.decoder == nil {
+		return 0, ErrNotInitialized
+	}
+	if s.opts.extractChannel {
+		return 1, nil
+	}
+	return int(max(s.decoder.Channels(), 1)), nil
+}
+
+// unread puts samples back in front of the buffered PCM.
+func (s *pcmStream) unread(samples []int16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samplesOut -= int64(len(samples))
+	rest := s.pcmBuffer[s.pcmOffset:]
+	s.pcmBuffer = append(append(make([]int16, 0, len(samples)+len(rest)), samples...), rest...)
+	s.pcmOffset = 0
+}
+
+// decodeNext reads and decodes the next frame. In look-ahead mode the frame
+// may already have been decoded in the background, and decoding of the
+// following frame is started before returning.
+func (s *pcmStream) decodeNext(ctx context.Context) decodedFrame {
+	if !s.opts.lookAhead {
+		return s.commitFrame(s.reconfigure(ctx, s.decodeFrame(ctx)))
+	}
+
+	var res decodedFrame
+	if s.pending != nil {
+		res = <-s.pending
+		s.pending = nil
+	} else {
+		res = s.decodeFrame(ctx)
+	}
+	res = s.commitFrame(s.reconfigure(ctx, res))
+
+	if res.err == nil {
+		s.pending = make(chan decodedFrame, 1)
+		go func(ctx context.Context, pending chan<- decodedFrame) {
+			pending <- s.decodeFrame(ctx)
+		}(context.WithoutCancel(ctx), s.pending)
+	}
+
+	return res
+}
+
+// decodeFrame reads one frame from the container and decodes it. It may run
+// on the look-ahead goroutine, so it only reads stream state that does not
+// change while a look-ahead decode is pending; the reading goroutine applies
+// the result with commitFrame.
+func (s *pcmStream) decodeFrame(ctx context.Context) decodedFrame {
+	frame, err := s.readNextFrame()
+	if errors.Is(err, ErrTruncated) && s.opts.allowTruncated {
+		return decodedFrame{err: io.EOF, frame: containerFrame{offset: -1}}
+	}
+	if err != nil {
+		return decodedFrame{err: err, frame: containerFrame{offset: -1}}
+	}
+	if frame.config != nil {
+		// The decoder is replaced on the reading goroutine, see reconfigure
+		return decodedFrame{frame: frame}
+	}
+	pcm, err := s.decodePayload(ctx, frame.data)
+	return decodedFrame{pcm: pcm, err: err, frame: frame}
+}
+
+// decodePayload decodes a frame read from the container.
+func (s *pcmStream) decodePayload(ctx context.Context, frame []byte) ([]int16, error) {
+	frame, err := s.opts.transform(frame)
+	if err != nil {
+		return nil, err
+	}
+	return s.decoder.Decode(ctx, frame)
+}
+
+// commitFrame applies the parsing progress of a decoded frame to the stream
+// state. It runs on the reading goroutine.
+//
+// With WithSkipCorruptFrames, a corrupt frame yields silence of the same
+// length as the previous frame instead of an error.
+func (s *pcmStream) commitFrame(res decodedFrame) decodedFrame {
+	if res.frame.data != nil {
+		s.bytesRead += int64(len(res.frame.data))
+		s.bytesParsed += res.frame.bytes
+		s.secondsParsed += res.frame.seconds
+		s.timestamp = res.frame.timestamp
+	}
+
+	if res.err == nil {
+		if len(res.pcm) > 0 {
+			s.frameSamples = len(res.pcm)
+		}
+		if s.opts.repeatConcealment {
+			s.lastFrame = append(s.lastFrame[:0], res.pcm...)
+		}
+		return res
+	}
+
+	if s.opts.skipCorruptFrames && isCorruptFrameError(res.err) {
+		s.framesSkipped++
+		res.err = nil
+		if len(s.lastFrame) > 0 {
+			res.pcm = s.lastFrame
+			s.lastFrame = nil
+			s.concealment.Repeated++
+			return res
+		}
+		s.concealment.Silenced++
+		res.pcm = make([]int16, s.frameSamples)
+		return res
+	}
+	return decodedFrame{err: res.err, frame: containerFrame{offset: -1}}
+}
+
+// reconfigure decodes a frame that starts a stream of another format with a
+// new decoder initialized from its config. Other frames are returned as is.
+// It must not run concurrently with a look-ahead decode.
+func (s *pcmStream) reconfigure(ctx context.Context, res decodedFrame) decodedFrame {
+	if res.frame.config == nil {
+		return res
+	}
+	if err := s.switchConfig(ctx, res.frame.config); err != nil {
+		return decodedFrame{err: err, frame: containerFrame{offset: -1}}
+	}
+	res.pcm, res.err = s.decodePayload(ctx, res.frame.data)
+	return res
+}
+
+// switchConfig replaces the decoder with one initialized from config, and
+// starts a new part of the stream for position tracking.
+func (s *pcmStream) switchConfig(ctx context.Context, config []byte) error {
+	asc, err := parseAudioSpecificConfig(config)
+	if err != nil {
+		return ErrInvalidConfig
+	}
+	if _, err := channelCount(asc.channelConfig); err != nil {
+		return err
+	}
+
+	decoder, err := NewDecoder(ctx, func(o *options) { *o = s.opts })
+	if err != nil {
+		return err
+	}
+	if err := decoder.Init(ctx, config); err != nil {
+		decoder.Close(ctx)
+		return err
+	}
+
+	// Close the current part at the samples produced so far
+	rate, outChannels := s.outputFormat()
+	s.parts = append(s.parts, streamPart{start: s.partStart, time: s.partTime, rate: rate, channels: outChannels})
+	s.partTime = s.outputTime(s.produced) - s.opts.startOffset
+	s.partStart = s.produced
+	if rate := s.asc.sampleRate; rate > 0 {
+		samples := (s.framesRead - s.partFrames) * coreFrameLength
+		s.partsDecoded += samplesDuration(samples, int64(rate))
+	}
+	s.partFrames = s.framesRead
+
+	_ = s.decoder.Close(ctx)
+	s.decoder = decoder
+	s.asc = asc
+	s.lastFrame = nil
+	s.frameSamples = 0
+
+	s.coreSampleRate = asc.sampleRate
+	s.sampleRate = decoder.SampleRate()
+	if !s.opts.extractChannel {
+		s.channels = decoder.Channels()
+	}
+	s.resetResampler()
+	if err := s.checkChannel(); err != nil {
+		return err
+	}
+	if s.opts.onFormatChange != nil {
+		s.opts.onFormatChange(s.sampleRate, s.channels)
+	}
+	return nil
+}
+
+// readDeadliner is implemented by readers supporting read deadlines, such as
+// net.Conn and os.File.
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// readNextFrame calls nextFrame, bounded by the WithReadTimeout limit.
+func (s *pcmStream) readNextFrame() (containerFrame, error) {
+	timeout := s.opts.readTimeout
+	if timeout <= 0 {
+		return s.nextFrame()
+	}
+	if s.stalled {
+		return containerFrame{}, ErrReadTimeout
+	}
+
+	if s.deadliner != nil && s.deadliner.SetReadDeadline(time.Now().Add(timeout)) == nil {
+		frame, err := s.nextFrame()
+		_ = s.deadliner.SetReadDeadline(time.Time{})
+		if isTimeout(err) {
+			return containerFrame{}, fmt.Errorf("%w: %w", ErrReadTimeout, err)
+		}
+		return frame, err
+	}
+
+	// No deadline support: wait for the read on another goroutine. If it is
+	// abandoned, the goroutine only advances the container parser, which is
+	// never used again, and its frame is dropped: stream state is updated by
+	// the caller from the returned frame.
+	type result struct {
+		frame containerFrame
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		frame, err := s.nextFrame()
+		done <- result{frame, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return res.frame, res.err
+	case <-timer.C:
+		s.stalled = true
+		return containerFrame{}, ErrReadTimeout
+	}
+}
+
+// isTimeout reports whether err is a read deadline expiry.
+func isTimeout(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isCorruptFrameError reports whether err affects a single frame only, so
+// that the stream can continue with the next frame.
+func isCorruptFrameError(err error) bool {
+	return IsRecoverable(err) || errors.Is(err, ErrInvalidADTS)
+}
+
+// coreFrameLength is the number of samples per channel in an AAC-LC core frame.
+const coreFrameLength = 1024
+
+// updateFormat updates the reported format from the size of a decoded frame.
+// When SBR is discovered in the bitstream, frames carry twice the core frame
+// length and the true output rate is double the signaled rate. The channel
+// count cannot change: FAAD2 outputs mono as stereo in case parametric stereo
+// (HE-AAC v2) appears, and a new config replaces the decoder.
+func (s *pcmStream) updateFormat(frameSamples int) {
+	channels := int(s.decoder.Channels())
+	if s.coreSampleRate == 0 || frameSamples == 0 || channels == 0 {
+		return
+	}
+
+	perChannel := frameSamples / channels
+	if perChannel != coreFrameLength && perChannel != 2*coreFrameLength {
+		return
+	}
+	rate := s.coreSampleRate * uint32(perChannel/coreFrameLength) //nolint:gosec // 1 or 2
+	if rate == s.sampleRate {
+		return
+	}
+	s.sampleRate = rate
+	if s.opts.onFormatChange != nil {
+		s.opts.onFormatChange(s.sampleRate, s.channels)
+	}
+}
+
+// setPlaybackRate changes the playback speed of subsequently decoded frames.
+func (s *pcmStream) setPlaybackRate(rate float64) error {
+	if !(rate > 0 && rate <= maxPlaybackRate) {
+		return ErrInvalidPlaybackRate
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrDecoderClosed
+	}
+	if s.decoder == nil {
+		return ErrNotInitialized
+	}
+
+	if rate == 1 {
+		s.resampler = nil
+		return nil
+	}
+	if s.resampler != nil {
+		s.resampler.rate = rate
+		return nil
+	}
+	s.resampler = newRateResampler(rate, int(s.decoder.Channels()))
+	return nil
+}
+
+// resetResampler rebuilds the resampler, if any, for the current decoder
+// output, keeping the playback rate. Interleaved input of another channel
+// count or rate must not be interpolated with the previous frames.
+func (s *pcmStream) resetResampler() {
+	if s.resampler != nil {
+		s.resampler = newRateResampler(s.resampler.rate, int(s.decoder.Channels()))
+	}
+}
+
+// playbackRate returns the current playback rate.
+func (s *pcmStream) playbackRate() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.resampler == nil {
+		return 1
+	}
+	return s.resampler.rate
+}
+
+// close releases the decoder. It is safe to call multiple times and from
+// another goroutine than read.
+//
+// A read in progress and a frame being decoded ahead are waited for first, so
+// close may block until the underlying reader returns.
+func (s *pcmStream) close(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if s.pending != nil {
+		<-s.pending
+		s.pending = nil
+	}
+	if s.decoder != nil {
+		err := s.decoder.Close(ctx)
+		s.decoder = nil
+		return err
+	}
+	return nil
+}
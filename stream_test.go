@@ -0,0 +1,915 @@
+package faad2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// readAllSamples reads r until EOF and returns the total number of samples.
+func readAllSamples(t *testing.T, read func(context.Context, []int16) (int, error), bufSize int) int {
+	t.Helper()
+	ctx := context.Background()
+	pcm := make([]int16, bufSize)
+	total := 0
+	for {
+		n, err := read(ctx, pcm)
+		total += n
+		if errors.Is(err, io.EOF) {
+			return total
+		}
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+	}
+}
+
+func TestLookAheadRead(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(30)
+
+	plain, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer plain.Close(ctx)
+
+	ahead, err := OpenADTS(ctx, bytes.NewReader(stream), WithLookAhead())
+	if err != nil {
+		t.Fatalf("OpenADTS with look-ahead failed: %v", err)
+	}
+	defer ahead.Close(ctx)
+
+	expected := readAllSamples(t, plain.Read, 1000)
+	got := readAllSamples(t, ahead.Read, 1000)
+
+	if got != expected {
+		t.Errorf("expected %d samples, got %d", expected, got)
+	}
+	if ahead.FramesRead() != plain.FramesRead() {
+		t.Errorf("expected %d frames, got %d", plain.FramesRead(), ahead.FramesRead())
+	}
+}
+
+func TestLookAheadInfoDuringRead(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(50)
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream), WithLookAhead(),
+		WithSizeHint(int64(len(stream))), WithSkipCorruptFrames())
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	// Poll the counters while frames are decoded in the background; run
+	// with -race to check that the look-ahead goroutine does not write them
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			_ = reader.Info()
+			_ = reader.FramesSkipped()
+			_ = reader.Concealment()
+			_ = reader.String()
+		}
+	}()
+
+	readAllSamples(t, reader.Read, 512)
+	close(done)
+	wg.Wait()
+
+	info := reader.Info()
+	if info.Frames != 50 {
+		t.Errorf("expected 50 frames, got %d", info.Frames)
+	}
+	want := time.Duration(50*1024) * time.Second / 44100
+	if diff := info.Duration - want; diff < -time.Millisecond || diff > time.Millisecond {
+		t.Errorf("expected duration %v, got %v", want, info.Duration)
+	}
+}
+
+func TestLookAheadCloseWhilePending(t *testing.T) {
+	ctx := context.Background()
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStream(10)), WithLookAhead())
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+
+	// Read one frame so that the next is decoded in the background
+	if _, err := reader.Read(ctx, make([]int16, 2048)); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if err := reader.Close(ctx); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
+
+func TestConcurrentCloseRead(t *testing.T) {
+	ctx := context.Background()
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStream(2000)), WithLookAhead())
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		pcm := make([]int16, 1000)
+		for {
+			if _, err := reader.Read(ctx, pcm); err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	if err := reader.Close(ctx); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	if err := <-done; !errors.Is(err, ErrDecoderClosed) && !errors.Is(err, io.EOF) {
+		t.Errorf("expected ErrDecoderClosed or io.EOF, got %v", err)
+	}
+	if err := reader.SetPlaybackRate(1.5); !errors.Is(err, ErrDecoderClosed) {
+		t.Errorf("SetPlaybackRate after Close: expected ErrDecoderClosed, got %v", err)
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(20)
+
+	plain, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer plain.Close(ctx)
+	expected := readAllSamples(t, plain.Read, 4096)
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	total := 0
+	for {
+		before := reader.FramesRead()
+		pcm, err := reader.ReadFrame(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadFrame failed: %v", err)
+		}
+		if got := reader.FramesRead() - before; got != 1 {
+			t.Fatalf("expected one frame per call, decoded %d", got)
+		}
+		if len(pcm) > maxFrameSamples(uint32(reader.decoder.Channels())) {
+			t.Fatalf("frame has %d samples", len(pcm))
+		}
+		total += len(pcm)
+	}
+
+	if total != expected {
+		t.Errorf("expected %d samples, got %d", expected, total)
+	}
+}
+
+func TestReadFrames(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(30)
+
+	plain, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer plain.Close(ctx)
+	total := readAllSamples(t, plain.Read, 4096)
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	if _, err := reader.ReadFrames(ctx, 0); !errors.Is(err, ErrInvalidFrameCount) {
+		t.Errorf("expected ErrInvalidFrameCount, got %v", err)
+	}
+
+	const frames = 960 // Opus 20 ms at 48 kHz
+	blockSize := frames * int(reader.decoder.Channels())
+	blocks := 0
+	for {
+		block, err := reader.ReadFrames(ctx, frames)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadFrames failed: %v", err)
+		}
+		if len(block) != blockSize {
+			t.Fatalf("block %d: expected %d samples, got %d", blocks, blockSize, len(block))
+		}
+		blocks++
+	}
+
+	if want := (total + blockSize - 1) / blockSize; blocks != want {
+		t.Errorf("expected %d blocks for %d samples, got %d", want, total, blocks)
+	}
+	if _, err := reader.ReadFrames(ctx, frames); !errors.Is(err, io.EOF) {
+		t.Errorf("expected sticky io.EOF, got %v", err)
+	}
+}
+
+func TestReadFramesKeepsSamplesOnError(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(10)
+	corruptTestFrame(stream, 3)
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	// A block spanning the corrupt frame fails as a whole
+	_, err = reader.ReadFrames(ctx, 4096)
+	if err == nil {
+		t.Fatal("expected error from corrupt frame")
+	}
+	if got := len(reader.pcmBuffer) - reader.pcmOffset; got == 0 {
+		t.Error("expected samples read before the error to be kept")
+	}
+}
+
+func TestWithChannel(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(20)
+
+	plain, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer plain.Close(ctx)
+	expected := readAllSamples(t, plain.Read, 4096) / int(plain.decoder.Channels())
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream), WithChannel(1))
+	if err != nil {
+		t.Fatalf("OpenADTS with WithChannel failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	if reader.Channels() != 1 {
+		t.Errorf("expected 1 channel, got %d", reader.Channels())
+	}
+	if got := readAllSamples(t, reader.Read, 4096); got != expected {
+		t.Errorf("expected %d samples, got %d", expected, got)
+	}
+
+	_, err = OpenADTS(ctx, bytes.NewReader(stream), WithChannel(2))
+	if !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("expected ErrInvalidChannel, got %v", err)
+	}
+}
+
+func TestSelectChannel(t *testing.T) {
+	dec := &Decoder{}
+	dec.channels.Store(3)
+	s := &pcmStream{decoder: dec, opts: newOptions([]Option{WithChannel(2)})}
+
+	got := s.selectChannel([]int16{1, 2, 3, 4, 5, 6})
+	if len(got) != 2 || got[0] != 3 || got[1] != 6 {
+		t.Errorf("expected [3 6], got %v", got)
+	}
+}
+
+func TestReadPlanar(t *testing.T) {
+	dec := &Decoder{}
+	dec.channels.Store(2)
+	s := &pcmStream{
+		decoder:   dec,
+		pcmBuffer: []int16{1, -1, 2, -2, 3, -3},
+		eof:       true,
+	}
+	ctx := context.Background()
+
+	if _, err := s.readPlanar(ctx, make([][]int16, 1)); !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("expected ErrInvalidChannel, got %v", err)
+	}
+
+	left, right := make([]int16, 2), make([]int16, 4)
+	n, err := s.readPlanar(ctx, [][]int16{left, right})
+	if n != 2 || err != nil {
+		t.Fatalf("expected (2, nil), got (%d, %v)", n, err)
+	}
+	if left[0] != 1 || left[1] != 2 || right[0] != -1 || right[1] != -2 {
+		t.Errorf("unexpected planar output: %v %v", left, right[:n])
+	}
+
+	n, err = s.readPlanar(ctx, [][]int16{left, right})
+	if n != 1 || err != nil || left[0] != 3 || right[0] != -3 {
+		t.Errorf("expected last sample pair, got (%d, %v) %v %v", n, err, left[:n], right[:n])
+	}
+
+	if n, err = s.readPlanar(ctx, [][]int16{left, right}); n != 0 || !errors.Is(err, io.EOF) {
+		t.Errorf("expected (0, io.EOF), got (%d, %v)", n, err)
+	}
+}
+
+func TestADTSReadPlanar(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(10)
+
+	plain, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer plain.Close(ctx)
+	expected := readAllSamples(t, plain.Read, 4096)
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	channels := int(reader.decoder.Channels())
+	out := make([][]int16, channels)
+	for c := range out {
+		out[c] = make([]int16, 1000)
+	}
+
+	total := 0
+	for {
+		n, err := reader.ReadPlanar(ctx, out)
+		total += n * channels
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadPlanar failed: %v", err)
+		}
+	}
+	if total != expected {
+		t.Errorf("expected %d samples, got %d", expected, total)
+	}
+}
+
+func TestPrimingSamples(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		freqIndex byte
+		want      int
+	}{
+		{"AAC-LC 44.1kHz", 4, 1024},
+		{"implicit SBR 22.05kHz", 7, 2048},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStreamAt(tt.freqIndex, 10)))
+			if err != nil {
+				t.Fatalf("OpenADTS failed: %v", err)
+			}
+			defer reader.Close(ctx)
+
+			if _, err := reader.Read(ctx, make([]int16, 4096)); err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			if got := reader.PrimingSamples(); got != tt.want {
+				t.Errorf("expected %d priming samples, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+// countingReader counts Read calls on the underlying reader.
+type countingReader struct {
+	r     io.Reader
+	calls int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	c.calls++
+	return c.r.Read(p)
+}
+
+func TestADTSBuffering(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(30)
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		maxCalls int
+		minCalls int
+	}{
+		{"default", nil, 5, 1},
+		{"unbuffered", []Option{WithBufferSize(0)}, 1000, 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &countingReader{r: bytes.NewReader(stream)}
+			reader, err := OpenADTS(ctx, src, tt.opts...)
+			if err != nil {
+				t.Fatalf("OpenADTS failed: %v", err)
+			}
+			defer reader.Close(ctx)
+
+			readAllSamples(t, reader.Read, 4096)
+			if src.calls < tt.minCalls || src.calls > tt.maxCalls {
+				t.Errorf("expected %d-%d underlying reads, got %d", tt.minCalls, tt.maxCalls, src.calls)
+			}
+		})
+	}
+}
+
+func TestReadEOFSemantics(t *testing.T) {
+	ctx := context.Background()
+	src := &countingReader{r: bytes.NewReader(buildTestADTSStream(3))}
+
+	reader, err := OpenADTS(ctx, src)
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	// Two decodable frames of 2048 samples remain after priming
+	pcm := make([]int16, 3000)
+	n, err := reader.Read(ctx, pcm)
+	if n != 3000 || err != nil {
+		t.Fatalf("first Read: expected (3000, nil), got (%d, %v)", n, err)
+	}
+
+	n, err = reader.Read(ctx, pcm)
+	if n != 1096 || err != nil {
+		t.Fatalf("second Read: expected (1096, nil), got (%d, %v)", n, err)
+	}
+
+	n, err = reader.Read(ctx, pcm)
+	if n != 0 || !errors.Is(err, io.EOF) {
+		t.Fatalf("third Read: expected (0, io.EOF), got (%d, %v)", n, err)
+	}
+
+	calls := src.calls
+	for range 3 {
+		n, err = reader.Read(ctx, pcm)
+		if n != 0 || !errors.Is(err, io.EOF) {
+			t.Errorf("Read after EOF: expected (0, io.EOF), got (%d, %v)", n, err)
+		}
+	}
+	if src.calls != calls {
+		t.Error("expected no underlying reads after EOF")
+	}
+
+	n, err = reader.Read(ctx, nil)
+	if n != 0 || err != nil {
+		t.Errorf("empty Read: expected (0, nil), got (%d, %v)", n, err)
+	}
+}
+
+func TestReadDeferredError(t *testing.T) {
+	ctx := context.Background()
+
+	// A truncated last frame produces an error after samples were read
+	stream := buildTestADTSStream(3)
+	stream = stream[:len(stream)-3]
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	pcm := make([]int16, 8192)
+	n, err := reader.Read(ctx, pcm)
+	if n != 2048 || err != nil {
+		t.Fatalf("first Read: expected (2048, nil), got (%d, %v)", n, err)
+	}
+
+	n, err = reader.Read(ctx, pcm)
+	if n != 0 || !errors.Is(err, ErrTruncated) {
+		t.Fatalf("second Read: expected (0, ErrTruncated), got (%d, %v)", n, err)
+	}
+}
+
+func TestAllowTruncated(t *testing.T) {
+	ctx := context.Background()
+
+	stream := buildTestADTSStream(3)
+	stream = stream[:len(stream)-3]
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream), WithAllowTruncated())
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	// The complete second frame decodes, the cut third frame ends the stream
+	if total := readAllSamples(t, reader.Read, 8192); total != 2048 {
+		t.Errorf("expected 2048 samples, got %d", total)
+	}
+}
+
+// corruptTestFrame overwrites the payload of frame i in a stream built by
+// buildTestADTSStream with data that fails to decode.
+func corruptTestFrame(stream []byte, i int) {
+	frameLen := 7 + len(silentStereoFrame)
+	for j := i*frameLen + 7; j < (i+1)*frameLen; j++ {
+		stream[j] = 0xFF
+	}
+}
+
+func TestSkipCorruptFrames(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(10)
+	corruptTestFrame(stream, 5)
+
+	// Without the option the corrupt frame stops the stream
+	strict, err := OpenADTS(ctx, bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer strict.Close(ctx)
+
+	pcm := make([]int16, 1000)
+	for {
+		_, err = strict.Read(ctx, pcm)
+		if err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrDecodeFailed) {
+		t.Errorf("expected ErrDecodeFailed, got %v", err)
+	}
+
+	lenient, err := OpenADTS(ctx, bytes.NewReader(stream), WithSkipCorruptFrames())
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer lenient.Close(ctx)
+
+	total := readAllSamples(t, lenient.Read, 1000)
+
+	// Nine decodable frames after priming, one replaced with silence
+	if total != 9*2048 {
+		t.Errorf("expected %d samples, got %d", 9*2048, total)
+	}
+	if lenient.FramesSkipped() != 1 {
+		t.Errorf("expected 1 skipped frame, got %d", lenient.FramesSkipped())
+	}
+}
+
+func TestReadTimeout(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(5)
+
+	tests := []struct {
+		name   string
+		pipe   func() (io.ReadCloser, io.WriteCloser)
+		sticky bool
+	}{
+		{"deadline", func() (io.ReadCloser, io.WriteCloser) { return net.Pipe() }, false},
+		{"abandoned", func() (io.ReadCloser, io.WriteCloser) { return io.Pipe() }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, w := tt.pipe()
+			defer r.Close()
+			defer w.Close()
+
+			// Write the frames, then stall without closing the stream
+			go w.Write(stream)
+
+			reader, err := OpenADTS(ctx, r, WithReadTimeout(50*time.Millisecond), WithBufferSize(0))
+			if err != nil {
+				t.Fatalf("OpenADTS failed: %v", err)
+			}
+			defer reader.Close(ctx)
+
+			pcm := make([]int16, 4096)
+			for {
+				_, err = reader.Read(ctx, pcm)
+				if err != nil {
+					break
+				}
+			}
+			if !errors.Is(err, ErrReadTimeout) {
+				t.Fatalf("expected ErrReadTimeout, got %v", err)
+			}
+			if got := reader.FramesRead(); got != 5 {
+				t.Errorf("expected 5 frames read before the stall, got %d", got)
+			}
+
+			_, err = reader.Read(ctx, pcm)
+			if tt.sticky && !errors.Is(err, ErrReadTimeout) {
+				t.Errorf("expected sticky ErrReadTimeout, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReadTimeoutAbandonedReadCompletes(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(10)
+	half := len(stream) / 2
+
+	for _, lookAhead := range []bool{false, true} {
+		r, w := io.Pipe()
+
+		// Write the first frames, then stall without closing the stream
+		go w.Write(stream[:half])
+
+		opts := []Option{WithReadTimeout(50 * time.Millisecond), WithBufferSize(0)}
+		if lookAhead {
+			opts = append(opts, WithLookAhead())
+		}
+		reader, err := OpenADTS(ctx, r, opts...)
+		if err != nil {
+			t.Fatalf("OpenADTS failed: %v", err)
+		}
+
+		pcm := make([]int16, 4096)
+		for {
+			_, err = reader.Read(ctx, pcm)
+			if err != nil {
+				break
+			}
+		}
+		if !errors.Is(err, ErrReadTimeout) {
+			t.Fatalf("expected ErrReadTimeout, got %v", err)
+		}
+		frames := reader.FramesRead()
+
+		// Unblock the abandoned read while the reader is still in use
+		go w.Write(stream[half:])
+		for range 5 {
+			if _, err := reader.Read(ctx, pcm); !errors.Is(err, ErrReadTimeout) {
+				t.Errorf("lookAhead=%v: expected sticky ErrReadTimeout, got %v", lookAhead, err)
+			}
+			_ = reader.Info()
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := reader.FramesRead(); got != frames {
+			t.Errorf("lookAhead=%v: expected %d frames read after the stall, got %d", lookAhead, frames, got)
+		}
+
+		reader.Close(ctx)
+		r.Close()
+	}
+}
+
+func TestFrameTransform(t *testing.T) {
+	ctx := context.Background()
+
+	// Obfuscate each payload by inverting its bytes
+	stream := buildTestADTSStream(5)
+	frameLen := 7 + len(silentStereoFrame)
+	for i := 0; i < len(stream); i += frameLen {
+		for j := i + 7; j < i+frameLen; j++ {
+			stream[j] ^= 0xFF
+		}
+	}
+
+	var calls int
+	invert := func(frame []byte) ([]byte, error) {
+		calls++
+		for i := range frame {
+			frame[i] ^= 0xFF
+		}
+		return frame, nil
+	}
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(stream), WithFrameTransform(invert))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	if total := readAllSamples(t, reader.Read, 4096); total != 4*2048 {
+		t.Errorf("expected %d samples, got %d", 4*2048, total)
+	}
+	if calls != 5 {
+		t.Errorf("expected transform to run on 5 frames, got %d", calls)
+	}
+
+	errKey := errors.New("missing key")
+	failing := func(frame []byte) ([]byte, error) {
+		if calls++; calls > 2 {
+			return nil, errKey
+		}
+		return frame, nil
+	}
+	calls = 0
+	reader, err = OpenADTS(ctx, bytes.NewReader(buildTestADTSStream(5)), WithFrameTransform(failing))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	pcm := make([]int16, 4096)
+	for err == nil {
+		_, err = reader.Read(ctx, pcm)
+	}
+	if !errors.Is(err, errKey) {
+		t.Errorf("expected transform error, got %v", err)
+	}
+}
+
+func TestStartEndOffset(t *testing.T) {
+	ctx := context.Background()
+	frame := 1024 * time.Second / 44100
+
+	tests := []struct {
+		name  string
+		opts  []Option
+		total int
+	}{
+		{"none", nil, 9 * 2048},
+		{"start", []Option{WithStartOffset(frame)}, 8 * 2048},
+		{"end", []Option{WithEndOffset(3 * frame)}, 3 * 2048},
+		{"mid-frame", []Option{WithStartOffset(frame / 2), WithEndOffset(2 * frame)}, 2048 + 1024},
+		{"empty", []Option{WithStartOffset(3 * frame), WithEndOffset(frame)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStream(10)), tt.opts...)
+			if err != nil {
+				t.Fatalf("OpenADTS failed: %v", err)
+			}
+			defer reader.Close(ctx)
+
+			if total := readAllSamples(t, reader.Read, 1000); total != tt.total {
+				t.Errorf("expected %d samples, got %d", tt.total, total)
+			}
+		})
+	}
+}
+
+func TestConcealment(t *testing.T) {
+	ctx := context.Background()
+	stream := buildTestADTSStream(10)
+	for _, i := range []int{5, 6, 8} {
+		corruptTestFrame(stream, i)
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want Concealment
+	}{
+		{"silence", []Option{WithSkipCorruptFrames()}, Concealment{Silenced: 3}},
+		{"repeat", []Option{WithSkipCorruptFrames(), WithRepeatConcealment()}, Concealment{Silenced: 1, Repeated: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := OpenADTS(ctx, bytes.NewReader(stream), tt.opts...)
+			if err != nil {
+				t.Fatalf("OpenADTS failed: %v", err)
+			}
+			defer reader.Close(ctx)
+
+			if total := readAllSamples(t, reader.Read, 1000); total != 9*2048 {
+				t.Errorf("expected %d samples, got %d", 9*2048, total)
+			}
+			if got := reader.Concealment(); got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+			if reader.FramesSkipped() != 3 {
+				t.Errorf("expected 3 skipped frames, got %d", reader.FramesSkipped())
+			}
+		})
+	}
+}
+
+func TestReadTimed(t *testing.T) {
+	ctx := context.Background()
+	frame := 1024 * time.Second / 44100
+
+	reader, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStream(5)), WithStartOffset(frame))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer reader.Close(ctx)
+
+	// Half a frame per read: 1024 interleaved stereo samples
+	pcm := make([]int16, 1024)
+	var starts []time.Duration
+	for {
+		n, start, err := reader.ReadTimed(ctx, pcm)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadTimed failed: %v", err)
+		}
+		if n != len(pcm) {
+			t.Fatalf("expected full reads, got %d", n)
+		}
+		starts = append(starts, start)
+	}
+
+	// Frames 1-3 after the skipped first frame, in halves
+	if len(starts) != 6 {
+		t.Fatalf("expected 6 reads, got %d", len(starts))
+	}
+	for i, start := range starts {
+		want := frame + time.Duration(i)*512*time.Second/44100
+		if d := start - want; d < -time.Microsecond || d > time.Microsecond {
+			t.Errorf("read %d: expected start %v, got %v", i, want, start)
+		}
+	}
+}
+
+func TestTee(t *testing.T) {
+	ctx := context.Background()
+
+	adts := buildTestADTSStream(5)
+	var adtsCopy bytes.Buffer
+	ar, err := OpenADTS(ctx, bytes.NewReader(adts), WithTee(&adtsCopy))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer ar.Close(ctx)
+	readAllSamples(t, ar.Read, 4096)
+	if !bytes.Equal(adtsCopy.Bytes(), adts) {
+		t.Errorf("ADTS copy differs from the stream: %d bytes, want %d", adtsCopy.Len(), len(adts))
+	}
+
+	flv := buildTestFLVStream(5)
+	var flvCopy bytes.Buffer
+	fr, err := OpenFLV(ctx, bytes.NewReader(flv), WithTee(&flvCopy))
+	if err != nil {
+		t.Fatalf("OpenFLV failed: %v", err)
+	}
+	defer fr.Close(ctx)
+	readAllSamples(t, fr.Read, 4096)
+	if !bytes.Equal(flvCopy.Bytes(), flv) {
+		t.Errorf("FLV copy differs from the stream: %d bytes, want %d", flvCopy.Len(), len(flv))
+	}
+
+	// The peak normalization scan is not copied
+	var scanCopy bytes.Buffer
+	ar, err = OpenADTS(ctx, bytes.NewReader(adts), WithTee(&scanCopy), WithPeakNormalization(0.5))
+	if err != nil {
+		t.Fatalf("OpenADTS failed: %v", err)
+	}
+	defer ar.Close(ctx)
+	readAllSamples(t, ar.Read, 4096)
+	if !bytes.Equal(scanCopy.Bytes(), adts) {
+		t.Errorf("normalized copy differs from the stream: %d bytes, want %d", scanCopy.Len(), len(adts))
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func TestTeeWriteError(t *testing.T) {
+	ctx := context.Background()
+
+	errDiskFull := errors.New("disk full")
+	_, err := OpenADTS(ctx, bytes.NewReader(buildTestADTSStream(5)), WithTee(failingWriter{errDiskFull}))
+	if !errors.Is(err, errDiskFull) {
+		t.Errorf("expected write error, got %v", err)
+	}
+}
+
+func TestSamplesDuration(t *testing.T) {
+	tests := []struct {
+		n, rate int64
+		want    time.Duration
+	}{
+		{0, 44100, 0},
+		{44100, 44100, time.Second},
+		{1024, 48000, 21333333 * time.Nanosecond},
+		// 100 hours at 96 kHz overflows n*time.Second
+		{100 * 3600 * 96000, 96000, 100 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := samplesDuration(tt.n, tt.rate); got != tt.want {
+			t.Errorf("samplesDuration(%d, %d): expected %v, got %v", tt.n, tt.rate, tt.want, got)
+		}
+	}
+}
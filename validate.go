@@ -0,0 +1,186 @@
+package faad2
+
+import "fmt"
+
+// Syntactic element IDs of a raw_data_block (ISO/IEC 14496-3 4.5.2.1).
+const (
+	idSCE = 0 // single channel element
+	idCPE = 1 // channel pair element
+	idCCE = 2 // coupling channel element
+	idLFE = 3 // LFE channel element
+	idDSE = 4 // data stream element
+	idPCE = 5 // program config element
+	idFIL = 6 // fill element
+	idEND = 7
+)
+
+// Scalefactor band counts per sampling frequency index, for long and short
+// windows (ISO/IEC 14496-3 4.5.4).
+var (
+	numSWBLong  = [...]int{41, 41, 47, 49, 49, 51, 47, 47, 43, 43, 43, 40, 40}
+	numSWBShort = [...]int{12, 12, 12, 14, 14, 14, 15, 15, 15, 15, 15, 15, 15}
+)
+
+// eightShortSequence is the window_sequence value of short-window frames.
+const eightShortSequence = 2
+
+// errMalformed marks frames rejected by ValidateFrame.
+func errMalformed(reason string) error {
+	return fmt.Errorf("%w: %s", ErrDecodeFailed, reason)
+}
+
+// ValidateFrame performs a quick syntactic check of an AAC frame against its
+// AudioSpecificConfig without decoding it, so ingest pipelines can reject
+// junk before committing decoder resources.
+//
+// frame is a raw AAC frame, optionally preceded by its ADTS header, whose
+// frame length must then match len(frame). The check covers the
+// configuration, the leading syntactic elements and their channel layout,
+// and the first channel's window parameters. It cannot prove that a frame
+// decodes: a nil error means the frame is plausible, not valid.
+//
+// Returns [ErrInvalidConfig], [ErrInvalidChannelConfig] or
+// [ErrUnsupportedSampleRate] for a bad config,
+// [ErrEmptyFrame] for an empty frame, [ErrInvalidADTS] for an inconsistent
+// ADTS header, or an error matching [ErrDecodeFailed] for a malformed frame.
+func ValidateFrame(config, frame []byte) error {
+	asc, err := parseAudioSpecificConfig(config)
+	if err != nil {
+		return ErrInvalidConfig
+	}
+	if _, err := channelCount(asc.channelConfig); err != nil {
+		return err
+	}
+	if err := asc.checkSampleRate(); err != nil {
+		return err
+	}
+	if asc.sampleRate == 0 {
+		return ErrInvalidConfig
+	}
+
+	if len(frame) >= 2 && frame[0] == 0xFF && frame[1]&0xF0 == 0xF0 {
+		_, _, frameLength, err := ParseADTSHeader(frame)
+		if err != nil {
+			return err
+		}
+		if int(frameLength) != len(frame) {
+			return &RangeError{Field: "ADTS frame length", Value: int64(frameLength), Err: ErrInvalidADTS}
+		}
+		headerLen := 7
+		if frame[1]&0x01 == 0 {
+			headerLen = 9 // CRC present
+		}
+		frame = frame[headerLen:]
+	}
+
+	if len(frame) == 0 {
+		return ErrEmptyFrame
+	}
+
+	switch asc.objectType {
+	case 1, 2, 3, 4:
+		// General audio syntax; with SBR/PS, objectType is the core type
+		return validateRawDataBlock(frame, asc)
+	default:
+		// Error-resilient and low-delay syntaxes are not checked
+		return nil
+	}
+}
+
+// validateRawDataBlock checks the first channel element of a raw_data_block,
+// skipping leading data and fill elements.
+func validateRawDataBlock(frame []byte, asc audioSpecificConfig) error {
+	br := bitReader{data: frame}
+
+	for {
+		id := br.read(3)
+		if br.overflow {
+			return errMalformed("truncated element")
+		}
+
+		switch id {
+		case idDSE:
+			br.read(4) // element_instance_tag
+			align := br.read(1) == 1
+			count := br.read(8)
+			if count == 255 {
+				count += br.read(8)
+			}
+			if align {
+				br.pos = (br.pos + 7) &^ 7
+			}
+			br.pos += int(count) * 8
+		case idFIL:
+			count := br.read(4)
+			if count == 15 {
+				count += br.read(8) - 1
+			}
+			br.pos += int(count) * 8
+		case idEND, idPCE:
+			return nil
+		case idCCE:
+			return errMalformed("coupling channel element before any channel")
+		default:
+			return validateChannelElement(&br, id, asc)
+		}
+
+		if br.pos > len(frame)*8 {
+			return errMalformed("element extends past end of frame")
+		}
+	}
+}
+
+// validateChannelElement checks the element type against the channel
+// configuration and the ics_info of the first channel.
+func validateChannelElement(br *bitReader, id uint32, asc audioSpecificConfig) error {
+	switch asc.channelConfig {
+	case 0:
+		// Layout given by a program config element; any channel element may start
+	case 2:
+		if id != idCPE {
+			return errMalformed("expected channel pair element for stereo")
+		}
+	default:
+		if id != idSCE {
+			return errMalformed("expected single channel element first")
+		}
+	}
+
+	br.read(4) // element_instance_tag
+
+	switch id {
+	case idCPE:
+		if br.read(1) == 0 {
+			// No common window: individual_channel_stream starts with global_gain
+			br.read(8)
+		}
+	default:
+		br.read(8) // global_gain
+	}
+
+	if br.read(1) != 0 {
+		return errMalformed("ics_reserved_bit set")
+	}
+	windowSequence := br.read(2)
+	br.read(1) // window_shape
+
+	if int(asc.samplingFreqIndex) >= len(numSWBLong) {
+		return nil
+	}
+	var maxSFB, numSWB int
+	if windowSequence == eightShortSequence {
+		maxSFB = int(br.read(4))
+		numSWB = numSWBShort[asc.samplingFreqIndex]
+	} else {
+		maxSFB = int(br.read(6))
+		numSWB = numSWBLong[asc.samplingFreqIndex]
+	}
+
+	if br.overflow {
+		return errMalformed("truncated channel element")
+	}
+	if maxSFB > numSWB {
+		return errMalformed("max_sfb exceeds scalefactor band count")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package faad2
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateFrame(t *testing.T) {
+	config := []byte{0x12, 0x10} // AAC-LC, 44.1 kHz, stereo
+	adtsFrame := buildTestADTSStream(1)
+
+	if err := ValidateFrame(config, silentStereoFrame); err != nil {
+		t.Errorf("raw frame: unexpected error %v", err)
+	}
+	if err := ValidateFrame(config, adtsFrame); err != nil {
+		t.Errorf("ADTS frame: unexpected error %v", err)
+	}
+
+	// Empty fill element (ID_FIL, count 0) before the channel pair
+	withFill := prependBits("1100000", silentStereoFrame)
+	if err := ValidateFrame(config, withFill); err != nil {
+		t.Errorf("frame with fill element: unexpected error %v", err)
+	}
+}
+
+func TestValidateFrameInvalid(t *testing.T) {
+	config := []byte{0x12, 0x10}
+	monoConfig := []byte{0x12, 0x08}
+
+	badLength := buildTestADTSStream(1)
+	badLength = badLength[:len(badLength)-1]
+
+	reservedBit := append([]byte(nil), silentStereoFrame...)
+	reservedBit[1] |= 0x80
+
+	tests := []struct {
+		name   string
+		config []byte
+		frame  []byte
+		err    error
+	}{
+		{"empty config", nil, silentStereoFrame, ErrInvalidConfig},
+		{"reserved channel config", []byte{0x12, 0x40}, silentStereoFrame, ErrInvalidChannelConfig},
+		{"empty frame", config, nil, ErrEmptyFrame},
+		{"ADTS length mismatch", config, badLength, ErrInvalidADTS},
+		{"stereo frame for mono config", monoConfig, silentStereoFrame, ErrDecodeFailed},
+		{"reserved bit", config, reservedBit, ErrDecodeFailed},
+		{"coupling first", config, []byte{0x40, 0x00}, ErrDecodeFailed},
+		{"junk", config, []byte{0x2F, 0xFF, 0xFF}, ErrDecodeFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateFrame(tt.config, tt.frame); !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+// prependBits returns data preceded by the given bits ("0"/"1" characters),
+// zero-padded to a whole byte.
+func prependBits(bits string, data []byte) []byte {
+	for _, b := range data {
+		for i := 7; i >= 0; i-- {
+			bits += string('0' + rune(b>>i&1))
+		}
+	}
+	for len(bits)%8 != 0 {
+		bits += "0"
+	}
+
+	out := make([]byte, len(bits)/8)
+	for i := range bits {
+		if bits[i] == '1' {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
@@ -1,9 +1,13 @@
 package faad2
 
 import (
+	"bytes"
 	"context"
 	_ "embed"
+	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
@@ -13,9 +17,17 @@ import (
 //go:embed faad2.wasm
 var faad2Wasm []byte
 
+// wasmContext is an instance of the FAAD2 WASM module.
+//
+// Exported functions and the C allocator are not goroutine-safe, so every call
+// into the module must hold mu. Decoders share the global instance by default;
+// decoders created with [WithDedicatedModule] own a private instance.
 type wasmContext struct {
-	runtime wazero.Runtime
-	module  api.Module
+	mu       sync.Mutex
+	runtime  wazero.Runtime
+	cache    wazero.CompilationCache
+	compiled wazero.CompiledModule
+	module   api.Module
 
 	// Cached function references
 	fnVersion  api.Function
@@ -26,16 +38,68 @@ type wasmContext struct {
 	fnGetError api.Function
 	fnMalloc   api.Function
 	fnFree     api.Function
+
+	// scratch is a small persistent allocation for call parameters, lazily
+	// allocated and shared by all decoders of this instance (calls hold mu).
+	scratch uint32
+
+	// closed is set when the instance is released. Dedicated instances also
+	// become unusable when their parent (the global instance) is shut down.
+	closed atomic.Bool
+	parent *wasmContext
 }
 
+// Layout of the scratch area.
+const (
+	scratchSampleRate = 0  // unsigned long (8 bytes)
+	scratchChannels   = 8  // unsigned char
+	scratchConfig     = 16 // AudioSpecificConfig bytes
+	scratchConfigSize = 48
+	scratchSize       = scratchConfig + scratchConfigSize
+)
+
 var (
 	globalCtx   *wasmContext
 	globalOnce  sync.Once
 	globalMu    sync.Mutex
 	errGlobal   error
 	globalReset bool
+
+	// watchdogCtx is a second runtime that closes modules when a call's
+	// context is done, used for decoders with a decode timeout. It is created
+	// on first use and released by Shutdown.
+	watchdogCtx *wasmContext
+
+	// compilationCacheDir is where compiled modules are cached ("" disables).
+	compilationCacheDir string
 )
 
+// SetCompilationCacheDir enables caching of the compiled WASM module in dir.
+//
+// Compiling the embedded FAAD2 module dominates start-up time. With a cache
+// directory, the compiled artifact is written on first use and loaded by later
+// processes, so short-lived CLI invocations start almost instantly. Use
+// [DefaultCompilationCacheDir] for a per-user location. An empty dir disables
+// the cache (the default).
+//
+// The setting applies the next time the runtime is initialized: before first
+// use, or after [Shutdown].
+func SetCompilationCacheDir(dir string) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	compilationCacheDir = dir
+}
+
+// DefaultCompilationCacheDir returns a per-user directory for
+// [SetCompilationCacheDir], located under [os.UserCacheDir].
+func DefaultCompilationCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-faad2", "wazero"), nil
+}
+
 func getWasmContext(ctx context.Context) (*wasmContext, error) {
 	globalMu.Lock()
 	defer globalMu.Unlock()
@@ -47,16 +111,32 @@ func getWasmContext(ctx context.Context) (*wasmContext, error) {
 	}
 
 	globalOnce.Do(func() {
-		globalCtx, errGlobal = initWasmContext(ctx)
+		globalCtx, errGlobal = initWasmContext(ctx, compilationCacheDir, false)
 	})
 	return globalCtx, errGlobal
 }
 
+// getWatchdogContext returns the runtime used for decoders with a decode
+// timeout, initializing it on first use.
+func getWatchdogContext(ctx context.Context) (*wasmContext, error) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	if watchdogCtx == nil {
+		wctx, err := initWasmContext(ctx, compilationCacheDir, true)
+		if err != nil {
+			return nil, err
+		}
+		watchdogCtx = wctx
+	}
+	return watchdogCtx, nil
+}
+
 // Shutdown releases the global WASM runtime and all associated resources.
 //
 // After calling Shutdown:
-//   - All existing [Decoder], [M4AReader], and [ADTSReader] instances become invalid
-//   - Calling methods on closed instances will return errors or panic
+//   - All existing [Decoder], [ADTSReader], and [FLVReader] instances become invalid
+//   - Calling methods on them returns [ErrDecoderClosed]; Close still succeeds
 //   - New instances can be created, which will lazily reinitialize the runtime
 //
 // Shutdown is optional but recommended when the application no longer needs
@@ -65,8 +145,26 @@ func Shutdown(ctx context.Context) error {
 	globalMu.Lock()
 	defer globalMu.Unlock()
 
+	if watchdogCtx != nil {
+		watchdogCtx.closed.Store(true)
+		_ = watchdogCtx.runtime.Close(ctx)
+		if watchdogCtx.cache != nil {
+			_ = watchdogCtx.cache.Close(ctx)
+		}
+		watchdogCtx = nil
+	}
+
 	if globalCtx != nil && globalCtx.runtime != nil {
+		// Wait for in-flight calls on the shared instance, and make every
+		// later call fail with ErrDecoderClosed.
+		globalCtx.mu.Lock()
+		defer globalCtx.mu.Unlock()
+		globalCtx.closed.Store(true)
+
 		err := globalCtx.runtime.Close(ctx)
+		if globalCtx.cache != nil {
+			_ = globalCtx.cache.Close(ctx)
+		}
 		globalCtx = nil
 		globalReset = true
 		errGlobal = nil
@@ -75,14 +173,35 @@ func Shutdown(ctx context.Context) error {
 	return nil
 }
 
-func initWasmContext(ctx context.Context) (*wasmContext, error) {
-	rt := wazero.NewRuntime(ctx)
+// initWasmContext creates a runtime and instantiates the module in it. With
+// closeOnDone, a module is closed when the context of a call into it is done,
+// aborting the call.
+func initWasmContext(ctx context.Context, cacheDir string, closeOnDone bool) (*wasmContext, error) {
+	config := wazero.NewRuntimeConfig().WithCloseOnContextDone(closeOnDone)
+
+	var cache wazero.CompilationCache
+	if cacheDir != "" {
+		c, err := wazero.NewCompilationCacheWithDir(cacheDir)
+		if err != nil {
+			return nil, err
+		}
+		cache = c
+		config = config.WithCompilationCache(cache)
+	}
+
+	rt := wazero.NewRuntimeWithConfig(ctx, config)
+	fail := func(err error) (*wasmContext, error) {
+		rt.Close(ctx)
+		if cache != nil {
+			_ = cache.Close(ctx)
+		}
+		return nil, err
+	}
 
 	// Instantiate WASI for fd_close, fd_write, fd_seek
 	_, err := wasi_snapshot_preview1.Instantiate(ctx, rt)
 	if err != nil {
-		rt.Close(ctx)
-		return nil, err
+		return fail(err)
 	}
 
 	// Provide the env module with emscripten_notify_memory_growth (no-op)
@@ -94,24 +213,53 @@ func initWasmContext(ctx context.Context) (*wasmContext, error) {
 		Export("emscripten_notify_memory_growth").
 		Instantiate(ctx)
 	if err != nil {
-		rt.Close(ctx)
-		return nil, err
+		return fail(err)
 	}
 
 	compiled, err := rt.CompileModule(ctx, faad2Wasm)
 	if err != nil {
-		rt.Close(ctx)
+		return fail(err)
+	}
+
+	wctx, err := instantiateWasm(ctx, rt, compiled)
+	if err != nil {
+		return fail(err)
+	}
+	wctx.cache = cache
+
+	return wctx, nil
+}
+
+// newDedicatedWasmContext instantiates a private copy of the FAAD2 module in
+// the global runtime, or in the watchdog runtime if watchdog is set. The
+// returned context must be released with close.
+func newDedicatedWasmContext(ctx context.Context, watchdog bool) (*wasmContext, error) {
+	get := getWasmContext
+	if watchdog {
+		get = getWatchdogContext
+	}
+	shared, err := get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wctx, err := instantiateWasm(ctx, shared.runtime, shared.compiled)
+	if err != nil {
 		return nil, err
 	}
+	wctx.parent = shared
+	return wctx, nil
+}
 
-	module, err := rt.InstantiateModule(ctx, compiled, wazero.NewModuleConfig())
+// instantiateWasm creates an anonymous module instance and caches its exports.
+func instantiateWasm(ctx context.Context, rt wazero.Runtime, compiled wazero.CompiledModule) (*wasmContext, error) {
+	module, err := rt.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(""))
 	if err != nil {
-		rt.Close(ctx)
 		return nil, err
 	}
 
-	wctx := &wasmContext{
+	return &wasmContext{
 		runtime:    rt,
+		compiled:   compiled,
 		module:     module,
 		fnVersion:  module.ExportedFunction("faad2_version"),
 		fnCreate:   module.ExportedFunction("faad2_decoder_create"),
@@ -121,9 +269,22 @@ func initWasmContext(ctx context.Context) (*wasmContext, error) {
 		fnGetError: module.ExportedFunction("faad2_get_error"),
 		fnMalloc:   module.ExportedFunction("malloc"),
 		fnFree:     module.ExportedFunction("free"),
+	}, nil
+}
+
+// close releases a dedicated module instance.
+func (w *wasmContext) close(ctx context.Context) error {
+	if w.isClosed() {
+		return nil
 	}
+	w.closed.Store(true)
+	return w.module.Close(ctx)
+}
 
-	return wctx, nil
+// isClosed reports whether the instance, or the runtime it lives in, has been
+// released. Calling into a closed instance is not allowed.
+func (w *wasmContext) isClosed() bool {
+	return w.closed.Load() || (w.parent != nil && w.parent.closed.Load())
 }
 
 // malloc allocates memory in the WASM module.
@@ -139,6 +300,19 @@ func (w *wasmContext) malloc(ctx context.Context, size uint32) (uint32, error) {
 	return ptr, nil
 }
 
+// scratchArea returns the instance's scratch area, allocating it on first use.
+// The caller must hold w.mu.
+func (w *wasmContext) scratchArea(ctx context.Context) (uint32, error) {
+	if w.scratch == 0 {
+		ptr, err := w.malloc(ctx, scratchSize)
+		if err != nil {
+			return 0, err
+		}
+		w.scratch = ptr
+	}
+	return w.scratch, nil
+}
+
 // free releases memory in the WASM module.
 func (w *wasmContext) free(ctx context.Context, ptr uint32) {
 	if ptr != 0 {
@@ -155,3 +329,17 @@ func (w *wasmContext) write(ptr uint32, data []byte) bool {
 func (w *wasmContext) read(ptr, size uint32) ([]byte, bool) {
 	return w.module.Memory().Read(ptr, size)
 }
+
+// readString returns the NUL-terminated string at ptr, reading at most limit
+// bytes.
+func (w *wasmContext) readString(ptr, limit uint32) string {
+	mem := w.module.Memory()
+	if ptr >= mem.Size() {
+		return ""
+	}
+	data, _ := mem.Read(ptr, min(limit, mem.Size()-ptr))
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		data = data[:i]
+	}
+	return string(data)
+}